@@ -2,6 +2,7 @@ package boards
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -12,6 +13,11 @@ const (
 	MinimumPasswordLength = 8
 )
 
+var (
+	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrInvalidInviteToken = errors.New("invalid invite token")
+)
+
 func NewErrAuthParam(msg string) *ErrAuthParam {
 	return &ErrAuthParam{
 		msg: msg,
@@ -0,0 +1,156 @@
+package boards
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const DefaultBatchConcurrency = 4
+
+// BatchOperation is an operation queued on a BatchExecutor, usually a
+// closure calling a client method, e.g. CreateCard or PatchBlock. The
+// context is the one passed to Run, and should be passed to the request
+// with WithContext so that cancelling the batch stops the operations that
+// are already running.
+type BatchOperation func(ctx context.Context) *Response
+
+// BatchResult summarizes the execution of a batch, keyed by the index of
+// each operation in the queue.
+type BatchResult struct {
+	// Succeeded contains the responses of the operations that succeeded
+	Succeeded map[int]*Response
+
+	// Failed contains the last response of the operations that failed
+	Failed map[int]*Response
+
+	// Skipped contains the indexes of the operations that were not run
+	// because the context was cancelled or an authentication error
+	// stopped the batch
+	Skipped []int
+}
+
+// BatchExecutor runs queued operations with bounded concurrency, retrying
+// the ones that fail transiently. A failed operation doesn't abort the
+// batch, but an authentication error stops running new operations.
+type BatchExecutor struct {
+	// Concurrency is the maximum number of operations run at the same time
+	Concurrency int
+
+	// RetryPolicy configures how transiently failing operations are
+	// retried
+	RetryPolicy RetryPolicy
+
+	operations []BatchOperation
+}
+
+// NewBatchExecutor creates a BatchExecutor with the default settings.
+func NewBatchExecutor() *BatchExecutor {
+	return &BatchExecutor{
+		Concurrency: DefaultBatchConcurrency,
+		RetryPolicy: DefaultRetryPolicy,
+	}
+}
+
+// Add queues an operation and returns its index.
+func (be *BatchExecutor) Add(op BatchOperation) int {
+	be.operations = append(be.operations, op)
+	return len(be.operations) - 1
+}
+
+// Run executes the queued operations and returns the result once all of
+// them have finished or have been skipped.
+func (be *BatchExecutor) Run(ctx context.Context) *BatchResult {
+	result := &BatchResult{
+		Succeeded: map[int]*Response{},
+		Failed:    map[int]*Response{},
+		Skipped:   []int{},
+	}
+
+	concurrency := be.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	stopped := false
+	isStopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil || isStopped() {
+					mu.Lock()
+					result.Skipped = append(result.Skipped, i)
+					mu.Unlock()
+					continue
+				}
+
+				resp := be.runOperation(ctx, be.operations[i])
+
+				mu.Lock()
+				if resp.Error == nil {
+					result.Succeeded[i] = resp
+				} else {
+					result.Failed[i] = resp
+					if resp.StatusCode == http.StatusUnauthorized {
+						stopped = true
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range be.operations {
+		if ctx.Err() != nil || isStopped() {
+			mu.Lock()
+			result.Skipped = append(result.Skipped, i)
+			mu.Unlock()
+			continue
+		}
+
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			mu.Lock()
+			result.Skipped = append(result.Skipped, i)
+			mu.Unlock()
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	sort.Ints(result.Skipped)
+
+	return result
+}
+
+// runOperation runs an operation, retrying it with the executor's retry
+// policy while it fails transiently.
+func (be *BatchExecutor) runOperation(ctx context.Context, op BatchOperation) *Response {
+	var resp *Response
+	_ = be.RetryPolicy.retry(ctx, func() bool {
+		resp = callOperation(ctx, op)
+		return resp.Error != nil && isTransientFailure(ctx, resp.StatusCode, resp.Error)
+	})
+	return resp
+}
+
+// callOperation runs an operation, recording a nil response as a failure.
+func callOperation(ctx context.Context, op BatchOperation) *Response {
+	resp := op(ctx)
+	if resp == nil {
+		return &Response{Error: ErrNoBatchResponse}
+	}
+	return resp
+}
@@ -0,0 +1,203 @@
+package boards
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestBatchExecutor() *BatchExecutor {
+	be := NewBatchExecutor()
+	be.Concurrency = 1
+	be.RetryPolicy.Interval = time.Millisecond
+	return be
+}
+
+func TestBatchExecutor(t *testing.T) {
+	t.Run("partial failure", func(t *testing.T) {
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response { return &Response{StatusCode: http.StatusOK} })
+		be.Add(func(context.Context) *Response {
+			return &Response{StatusCode: http.StatusForbidden, Error: errors.New("forbidden")}
+		})
+		be.Add(func(context.Context) *Response { return &Response{StatusCode: http.StatusOK} })
+
+		result := be.Run(context.Background())
+		if len(result.Succeeded) != 2 || result.Succeeded[0] == nil || result.Succeeded[2] == nil {
+			t.Errorf("expected operations 0 and 2 to succeed, got %v", result.Succeeded)
+		}
+		if len(result.Failed) != 1 || result.Failed[1] == nil {
+			t.Errorf("expected operation 1 to fail, got %v", result.Failed)
+		}
+		if len(result.Skipped) != 0 {
+			t.Errorf("expected nothing to be skipped, got %v", result.Skipped)
+		}
+	})
+
+	t.Run("retries transient failures until exhausted", func(t *testing.T) {
+		var calls int32
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response {
+			atomic.AddInt32(&calls, 1)
+			return &Response{StatusCode: http.StatusServiceUnavailable, Error: errors.New("unavailable")}
+		})
+
+		result := be.Run(context.Background())
+		if result.Failed[0] == nil {
+			t.Fatalf("expected the operation to fail, got %+v", result)
+		}
+		if expected := int32(be.RetryPolicy.MaxAttempts); calls != expected {
+			t.Errorf("expected %d calls, got %d", expected, calls)
+		}
+	})
+
+	t.Run("retries network errors", func(t *testing.T) {
+		var calls int32
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return &Response{Error: &url.Error{Op: "Get", URL: "http://localhost", Err: errors.New("connection refused")}}
+			}
+			return &Response{StatusCode: http.StatusOK}
+		})
+
+		result := be.Run(context.Background())
+		if result.Succeeded[0] == nil {
+			t.Errorf("expected the operation to succeed, got %+v", result)
+		}
+		if calls != 2 {
+			t.Errorf("expected 2 calls, got %d", calls)
+		}
+	})
+
+	t.Run("doesn't retry validation errors", func(t *testing.T) {
+		var calls int32
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response {
+			atomic.AddInt32(&calls, 1)
+			return &Response{Error: ErrEmptyPatch}
+		})
+
+		result := be.Run(context.Background())
+		if !errors.Is(result.Failed[0].Error, ErrEmptyPatch) {
+			t.Errorf("expected ErrEmptyPatch, got %+v", result.Failed[0])
+		}
+		if calls != 1 {
+			t.Errorf("expected a single call, got %d", calls)
+		}
+	})
+
+	t.Run("records a nil response as a failure", func(t *testing.T) {
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response { return nil })
+
+		result := be.Run(context.Background())
+		if resp := result.Failed[0]; resp == nil || !errors.Is(resp.Error, ErrNoBatchResponse) {
+			t.Errorf("expected ErrNoBatchResponse, got %+v", resp)
+		}
+	})
+
+	t.Run("unauthorized stops the batch", func(t *testing.T) {
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response {
+			return &Response{StatusCode: http.StatusUnauthorized, Error: errors.New("unauthorized")}
+		})
+		be.Add(func(context.Context) *Response {
+			t.Error("unexpected call after an unauthorized response")
+			return &Response{StatusCode: http.StatusOK}
+		})
+
+		result := be.Run(context.Background())
+		if result.Failed[0] == nil {
+			t.Errorf("expected operation 0 to fail, got %+v", result)
+		}
+		if !reflect.DeepEqual(result.Skipped, []int{1}) {
+			t.Errorf("expected operation 1 to be skipped, got %v", result.Skipped)
+		}
+	})
+
+	t.Run("cancellation skips the remaining operations", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		be := newTestBatchExecutor()
+		be.Add(func(context.Context) *Response {
+			cancel()
+			return &Response{StatusCode: http.StatusOK}
+		})
+		be.Add(func(context.Context) *Response {
+			t.Error("unexpected call after cancellation")
+			return &Response{StatusCode: http.StatusOK}
+		})
+		be.Add(func(context.Context) *Response {
+			t.Error("unexpected call after cancellation")
+			return &Response{StatusCode: http.StatusOK}
+		})
+
+		result := be.Run(ctx)
+		if result.Succeeded[0] == nil {
+			t.Errorf("expected operation 0 to succeed, got %+v", result)
+		}
+		if !reflect.DeepEqual(result.Skipped, []int{1, 2}) {
+			t.Errorf("expected operations 1 and 2 to be skipped, got %v", result.Skipped)
+		}
+	})
+
+	t.Run("cancellation reaches running operations", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		started := make(chan struct{})
+		be := newTestBatchExecutor()
+		be.Concurrency = 2
+		be.Add(func(ctx context.Context) *Response {
+			close(started)
+			<-ctx.Done()
+			return &Response{Error: ctx.Err()}
+		})
+		be.Add(func(context.Context) *Response {
+			<-started
+			cancel()
+			return &Response{StatusCode: http.StatusOK}
+		})
+
+		result := be.Run(ctx)
+		if resp := result.Failed[0]; resp == nil || !errors.Is(resp.Error, context.Canceled) {
+			t.Errorf("expected operation 0 to be cancelled, got %+v", resp)
+		}
+		if result.Succeeded[1] == nil {
+			t.Errorf("expected operation 1 to succeed, got %+v", result)
+		}
+	})
+
+	t.Run("cancellation stops running client requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var requests int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			cancel()
+			<-r.Context().Done()
+		})
+
+		be := newTestBatchExecutor()
+		be.Add(func(ctx context.Context) *Response {
+			_, resp := client.GetBoard("board1", "", WithContext(ctx))
+			return resp
+		})
+
+		result := be.Run(ctx)
+		if resp := result.Failed[0]; resp == nil || !errors.Is(resp.Error, context.Canceled) {
+			t.Errorf("expected the request to be cancelled, got %+v", resp)
+		}
+		if requests := atomic.LoadInt32(&requests); requests != 1 {
+			t.Errorf("expected a single request, got %d", requests)
+		}
+	})
+}
@@ -3,6 +3,7 @@ package boards
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"unicode/utf8"
 
@@ -85,27 +86,27 @@ type Block struct {
 type BlockPatch struct {
 	// The id for this block's parent block. Empty for root blocks
 	// required: false
-	ParentID *string `json:"parentId"`
+	ParentID *string `json:"parentId,omitempty"`
 
 	// The schema version of this block
 	// required: false
-	Schema *int64 `json:"schema"`
+	Schema *int64 `json:"schema,omitempty"`
 
 	// The block type
 	// required: false
-	Type *BlockType `json:"type"`
+	Type *BlockType `json:"type,omitempty"`
 
 	// The display title
 	// required: false
-	Title *string `json:"title"`
+	Title *string `json:"title,omitempty"`
 
 	// The block updated fields
 	// required: false
-	UpdatedFields map[string]interface{} `json:"updatedFields"`
+	UpdatedFields map[string]interface{} `json:"updatedFields,omitempty"`
 
 	// The block removed fields
 	// required: false
-	DeletedFields []string `json:"deletedFields"`
+	DeletedFields []string `json:"deletedFields,omitempty"`
 }
 
 // BlockPatchBatch is a batch of IDs and patches for modify blocks
@@ -118,6 +119,22 @@ type BlockPatchBatch struct {
 	BlockPatches []BlockPatch `json:"block_patches"`
 }
 
+// PartialBlockPatchErr is returned when patching blocks one by one fails
+// midway, reporting which blocks were already patched.
+type PartialBlockPatchErr struct {
+	PatchedIDs []string
+	FailedID   string
+	Err        error
+}
+
+func (e PartialBlockPatchErr) Error() string {
+	return fmt.Sprintf("patching block %s failed after %d blocks were patched: %s", e.FailedID, len(e.PatchedIDs), e.Err)
+}
+
+func (e PartialBlockPatchErr) Unwrap() error {
+	return e.Err
+}
+
 // BoardModifier is a callback that can modify each board during an import.
 // A cache of arbitrary data will be passed for each call and any changes
 // to the cache will be preserved for the next call.
@@ -174,6 +191,16 @@ func (b *Block) LogClone() interface{} {
 	}
 }
 
+// IsValid checks that the block type, if set, is a valid block type.
+func (p *BlockPatch) IsValid() error {
+	if p.Type != nil {
+		if _, err := BlockTypeFromString(string(*p.Type)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Patch returns an update version of the block.
 func (p *BlockPatch) Patch(block *Block) *Block {
 	if p.ParentID != nil {
@@ -105,6 +105,31 @@ type Board struct {
 	DeleteAt int64 `json:"deleteAt"`
 }
 
+// BoardSummary holds the fields of a board needed to list it, like in a
+// template picker
+// swagger:model
+type BoardSummary struct {
+	// The ID for the board
+	// required: true
+	ID string `json:"id"`
+
+	// The title of the board
+	// required: false
+	Title string `json:"title"`
+
+	// The icon of the board
+	// required: false
+	Icon string `json:"icon"`
+
+	// The description of the board
+	// required: false
+	Description string `json:"description"`
+
+	// Marks the template boards
+	// required: false
+	IsTemplate bool `json:"isTemplate"`
+}
+
 // GetPropertyString returns the value of the specified property as a string,
 // or error if the property does not exist or is not of type string.
 func (b *Board) GetPropertyString(propName string) (string, error) {
@@ -125,47 +150,47 @@ func (b *Board) GetPropertyString(propName string) (string, error) {
 type BoardPatch struct {
 	// The type of the board
 	// required: false
-	Type *BoardType `json:"type"`
+	Type *BoardType `json:"type,omitempty"`
 
 	// The minimum role applied when somebody joins the board
 	// required: false
-	MinimumRole *BoardRole `json:"minimumRole"`
+	MinimumRole *BoardRole `json:"minimumRole,omitempty"`
 
 	// The title of the board
 	// required: false
-	Title *string `json:"title"`
+	Title *string `json:"title,omitempty"`
 
 	// The description of the board
 	// required: false
-	Description *string `json:"description"`
+	Description *string `json:"description,omitempty"`
 
 	// The icon of the board
 	// required: false
-	Icon *string `json:"icon"`
+	Icon *string `json:"icon,omitempty"`
 
 	// Indicates if the board shows the description on the interface
 	// required: false
-	ShowDescription *bool `json:"showDescription"`
+	ShowDescription *bool `json:"showDescription,omitempty"`
 
 	// Indicates if the board shows the description on the interface
 	// required: false
-	ChannelID *string `json:"channelId"`
+	ChannelID *string `json:"channelId,omitempty"`
 
 	// The board updated properties
 	// required: false
-	UpdatedProperties map[string]interface{} `json:"updatedProperties"`
+	UpdatedProperties map[string]interface{} `json:"updatedProperties,omitempty"`
 
 	// The board removed properties
 	// required: false
-	DeletedProperties []string `json:"deletedProperties"`
+	DeletedProperties []string `json:"deletedProperties,omitempty"`
 
 	// The board updated card properties
 	// required: false
-	UpdatedCardProperties []map[string]interface{} `json:"updatedCardProperties"`
+	UpdatedCardProperties []map[string]interface{} `json:"updatedCardProperties,omitempty"`
 
 	// The board removed card properties
 	// required: false
-	DeletedCardProperties []string `json:"deletedCardProperties"`
+	DeletedCardProperties []string `json:"deletedCardProperties,omitempty"`
 }
 
 // BoardMember stores the information of the membership of a user on a board
@@ -208,6 +233,25 @@ type BoardMember struct {
 	Synthetic bool `json:"synthetic"`
 }
 
+// SetRole sets the scheme flags of the member for the given role, leaving
+// the rest of the fields untouched. Roles are cumulative, so an editor is
+// also a commenter and a viewer.
+func (bm *BoardMember) SetRole(role BoardRole) error {
+	switch role {
+	case BoardRoleAdmin:
+		bm.SchemeAdmin, bm.SchemeEditor, bm.SchemeCommenter, bm.SchemeViewer = true, true, true, true
+	case BoardRoleEditor:
+		bm.SchemeAdmin, bm.SchemeEditor, bm.SchemeCommenter, bm.SchemeViewer = false, true, true, true
+	case BoardRoleCommenter:
+		bm.SchemeAdmin, bm.SchemeEditor, bm.SchemeCommenter, bm.SchemeViewer = false, false, true, true
+	case BoardRoleViewer:
+		bm.SchemeAdmin, bm.SchemeEditor, bm.SchemeCommenter, bm.SchemeViewer = false, false, false, true
+	default:
+		return InvalidBoardErr{"invalid-board-member-role"}
+	}
+	return nil
+}
+
 // BoardMetadata contains metadata for a Board
 // swagger:model
 type BoardMetadata struct {
@@ -244,6 +288,12 @@ func BoardsFromJSON(data io.Reader) []*Board {
 	return boards
 }
 
+func BoardSummariesFromJSON(data io.Reader) []*BoardSummary {
+	var summaries []*BoardSummary
+	_ = json.NewDecoder(data).Decode(&summaries)
+	return summaries
+}
+
 func BoardMemberFromJSON(data io.Reader) *BoardMember {
 	var boardMember *BoardMember
 	_ = json.NewDecoder(data).Decode(&boardMember)
@@ -0,0 +1,204 @@
+package boards
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MultiValueSeparator separates the values of multi-select and
+// multi-person properties when they are exported as a single cell.
+const MultiValueSeparator = "; "
+
+// WriteBoardCSV writes the cards as CSV, with a title column followed by
+// one column per card property of the board. If propertyIDs is not empty
+// only those properties are written, in that order. Option and user IDs
+// are resolved to their labels, the latter only if a resolver is provided.
+func WriteBoardCSV(w io.Writer, board *Board, cards []*Card, propertyIDs []string, resolver PropValueResolver) error {
+	schema, err := ParsePropertySchema(board)
+	if err != nil {
+		return err
+	}
+
+	propDefs := sortedPropDefs(schema, propertyIDs)
+
+	header := []string{"Title"}
+	for _, pd := range propDefs {
+		header = append(header, pd.Name)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		row := []string{card.Title}
+		for _, pd := range propDefs {
+			row = append(row, propertyLabel(pd, card.Properties[pd.ID], resolver))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// viewVisiblePropertyIDs returns the IDs of the card properties shown by
+// a view block.
+func viewVisiblePropertyIDs(view *Block) ([]string, error) {
+	switch ids := view.Fields["visiblePropertyIds"].(type) {
+	case nil:
+		return []string{}, nil
+	case []string:
+		return ids, nil
+	case []interface{}:
+		propertyIDs := make([]string, 0, len(ids))
+		for _, id := range ids {
+			propertyID, ok := id.(string)
+			if !ok {
+				return nil, ErrInvalidFieldType{"visiblePropertyIds item"}
+			}
+			propertyIDs = append(propertyIDs, propertyID)
+		}
+		return propertyIDs, nil
+	default:
+		return nil, ErrInvalidFieldType{"visiblePropertyIds"}
+	}
+}
+
+// WriteBoardMarkdown writes the cards as Markdown. Each card is rendered
+// as a heading followed by its properties as a bullet list and its
+// content blocks, taken from blocks, in content order. Comments are
+// rendered as block quotes.
+func WriteBoardMarkdown(w io.Writer, board *Board, cards []*Card, blocks []*Block, resolver PropValueResolver) error {
+	schema, err := ParsePropertySchema(board)
+	if err != nil {
+		return err
+	}
+
+	propDefs := sortedPropDefs(schema, nil)
+
+	var sb strings.Builder
+	sb.WriteString("# " + strings.TrimSpace(board.Icon+" "+board.Title) + "\n")
+	if board.Description != "" {
+		sb.WriteString("\n" + board.Description + "\n")
+	}
+
+	for _, card := range cards {
+		sb.WriteString("\n## " + strings.TrimSpace(card.Icon+" "+card.Title) + "\n")
+
+		properties := []string{}
+		for _, pd := range propDefs {
+			if value := propertyLabel(pd, card.Properties[pd.ID], resolver); value != "" {
+				properties = append(properties, fmt.Sprintf("- **%s**: %s\n", pd.Name, value))
+			}
+		}
+		if len(properties) != 0 {
+			sb.WriteString("\n" + strings.Join(properties, ""))
+		}
+
+		for _, block := range OrderContentBlocks(card, blocks) {
+			if content := contentBlockMarkdown(block); content != "" {
+				sb.WriteString("\n" + content + "\n")
+			}
+		}
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// contentBlockMarkdown renders a content block as Markdown. Blocks
+// without a textual representation are rendered as an empty string.
+func contentBlockMarkdown(block *Block) string {
+	switch block.Type {
+	case TypeText:
+		return block.Title
+	case TypeComment:
+		lines := strings.Split(block.Title, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return strings.Join(lines, "\n")
+	case TypeCheckbox:
+		checked, _ := block.Fields["value"].(bool)
+		if checked {
+			return "- [x] " + block.Title
+		}
+		return "- [ ] " + block.Title
+	case TypeDivider:
+		return "---"
+	}
+	return ""
+}
+
+// sortedPropDefs returns the property definitions for propertyIDs, or all
+// of the schema's definitions in board order if propertyIDs is empty.
+func sortedPropDefs(schema PropSchema, propertyIDs []string) []PropDef {
+	propDefs := []PropDef{}
+	if len(propertyIDs) != 0 {
+		for _, id := range propertyIDs {
+			if pd, ok := schema[id]; ok {
+				propDefs = append(propDefs, pd)
+			}
+		}
+		return propDefs
+	}
+
+	for _, pd := range schema {
+		propDefs = append(propDefs, pd)
+	}
+	sort.Slice(propDefs, func(i, j int) bool {
+		return propDefs[i].Index < propDefs[j].Index
+	})
+	return propDefs
+}
+
+// propertyLabel returns the human readable value of a card property. Values
+// that can't be resolved are returned as they are.
+func propertyLabel(pd PropDef, v interface{}, resolver PropValueResolver) string {
+	if v == nil {
+		return ""
+	}
+
+	switch pd.Type {
+	case "select":
+		if id, ok := v.(string); ok {
+			if opt, ok := pd.Options[id]; ok {
+				return opt.Value
+			}
+		}
+	case "multiSelect":
+		if ids, ok := v.([]interface{}); ok {
+			values := make([]string, 0, len(ids))
+			for _, idIface := range ids {
+				id := fmt.Sprintf("%v", idIface)
+				if opt, ok := pd.Options[id]; ok {
+					values = append(values, opt.Value)
+				} else {
+					values = append(values, id)
+				}
+			}
+			return strings.Join(values, MultiValueSeparator)
+		}
+	case "multiPerson":
+		if ids, ok := v.([]interface{}); ok {
+			values := make([]string, 0, len(ids))
+			for _, id := range ids {
+				values = append(values, propertyLabel(PropDef{Type: "person"}, id, resolver))
+			}
+			return strings.Join(values, MultiValueSeparator)
+		}
+	}
+
+	value, err := pd.GetValue(v, resolver)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return value
+}
@@ -0,0 +1,194 @@
+package boards
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestExportBoard() *Board {
+	return &Board{
+		ID:    "board1",
+		Title: "Roadmap",
+		CardProperties: []map[string]interface{}{
+			{
+				"id":   "status",
+				"name": "Status",
+				"type": "select",
+				"options": []interface{}{
+					map[string]interface{}{"id": "todo", "value": "To do"},
+					map[string]interface{}{"id": "done", "value": "Done"},
+				},
+			},
+			{
+				"id":   "tags",
+				"name": "Tags",
+				"type": "multiSelect",
+				"options": []interface{}{
+					map[string]interface{}{"id": "bug", "value": "Bug"},
+					map[string]interface{}{"id": "ui", "value": "UI"},
+				},
+			},
+			{"id": "owner", "name": "Owner", "type": "person"},
+			{"id": "notes", "name": "Notes", "type": "text"},
+		},
+	}
+}
+
+// newTestExportClient serves the board returned by newTestExportBoard
+// along with the given cards and blocks.
+func newTestExportClient(t *testing.T, cards []*Card, blocks []*Block) *Client {
+	t.Helper()
+
+	users := map[string]User{"user1": {ID: "user1", Username: "alice"}}
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v2/boards/board1":
+			writeJSON(t, w, http.StatusOK, newTestExportBoard())
+		case r.URL.Path == "/api/v2/boards/board1/cards":
+			if r.URL.Query().Get("page") != "0" {
+				writeJSON(t, w, http.StatusOK, []*Card{})
+				return
+			}
+			writeJSON(t, w, http.StatusOK, cards)
+		case r.URL.Path == "/api/v2/boards/board1/blocks":
+			writeJSON(t, w, http.StatusOK, blocks)
+		case strings.HasPrefix(r.URL.Path, "/api/v2/users/"):
+			user, ok := users[strings.TrimPrefix(r.URL.Path, "/api/v2/users/")]
+			if !ok {
+				writeError(t, w, http.StatusNotFound, "user not found")
+				return
+			}
+			writeJSON(t, w, http.StatusOK, user)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+}
+
+func TestExportBoardCSV(t *testing.T) {
+	cards := []*Card{
+		{
+			ID:    "card1",
+			Title: "Fix login",
+			Properties: map[string]any{
+				"status": "done",
+				"tags":   []any{"bug", "ui"},
+				"owner":  "user1",
+				"notes":  "Needs a review",
+			},
+		},
+		{
+			ID:         "card2",
+			Title:      "Unassigned",
+			Properties: map[string]any{"owner": "user2"},
+		},
+	}
+	view := &Block{
+		ID:      "view1",
+		BoardID: "board1",
+		Type:    TypeView,
+		Fields:  map[string]interface{}{"visiblePropertyIds": []interface{}{"owner", "status"}},
+	}
+
+	t.Run("all properties", func(t *testing.T) {
+		client := newTestExportClient(t, cards, nil)
+
+		var sb strings.Builder
+		if resp := client.ExportBoardCSV("board1", "", &sb); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+
+		lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected a header and 2 rows, got %q", sb.String())
+		}
+		if expected := "Title,Status,Tags,Owner,Notes"; lines[0] != expected {
+			t.Errorf("expected header %q, got %q", expected, lines[0])
+		}
+		if expected := "Fix login,Done,Bug; UI,alice,Needs a review"; lines[1] != expected {
+			t.Errorf("expected row %q, got %q", expected, lines[1])
+		}
+		if expected := "Unassigned,,,user2,"; lines[2] != expected {
+			t.Errorf("expected row %q, got %q", expected, lines[2])
+		}
+	})
+
+	t.Run("view properties", func(t *testing.T) {
+		client := newTestExportClient(t, cards, []*Block{view})
+
+		var sb strings.Builder
+		if resp := client.ExportBoardCSV("board1", "view1", &sb); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+
+		lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+		if expected := "Title,Owner,Status"; lines[0] != expected {
+			t.Errorf("expected header %q, got %q", expected, lines[0])
+		}
+		if expected := "Fix login,alice,Done"; lines[1] != expected {
+			t.Errorf("expected row %q, got %q", expected, lines[1])
+		}
+	})
+
+	t.Run("unknown view", func(t *testing.T) {
+		client := newTestExportClient(t, cards, []*Block{view})
+
+		var sb strings.Builder
+		resp := client.ExportBoardCSV("board1", "view2", &sb)
+		if resp.Error == nil {
+			t.Error("expected an error")
+		}
+		if sb.Len() != 0 {
+			t.Errorf("expected nothing to be written, got %q", sb.String())
+		}
+	})
+}
+
+func TestExportBoardMarkdown(t *testing.T) {
+	cards := []*Card{
+		{
+			ID:           "card1",
+			Title:        "Fix login",
+			Icon:         "🐛",
+			ContentOrder: []string{"text2", "text1"},
+			Properties:   map[string]any{"status": "done", "owner": "user1"},
+		},
+		{ID: "card2", Title: "Empty"},
+	}
+	blocks := []*Block{
+		{ID: "text1", ParentID: "card1", Type: TypeText, Title: "Second paragraph"},
+		{ID: "text2", ParentID: "card1", Type: TypeText, Title: "First paragraph"},
+		{ID: "comment1", ParentID: "card1", Type: TypeComment, Title: "Looks good\n\nShip it", CreateAt: 2},
+		{ID: "check1", ParentID: "card1", Type: TypeCheckbox, Title: "Tested", Fields: map[string]interface{}{"value": true}, CreateAt: 1},
+	}
+	client := newTestExportClient(t, cards, blocks)
+
+	var sb strings.Builder
+	if resp := client.ExportBoardMarkdown("board1", &sb); resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := `# Roadmap
+
+## 🐛 Fix login
+
+- **Status**: Done
+- **Owner**: alice
+
+First paragraph
+
+Second paragraph
+
+- [x] Tested
+
+> Looks good
+>
+> Ship it
+
+## Empty
+`
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
@@ -3,13 +3,17 @@ package boards
 import (
 	"encoding/json"
 	"io"
+	"time"
 
 	model "github.com/mattermost/mattermost/server/public/model"
 )
 
 // BoardInsightsList is a response type with pagination support.
 type BoardInsightsList struct {
-//	model.InsightsListData
+	// True if there is a next page for pagination
+	// required: true
+	HasNext bool `json:"has_next"`
+
 	Items []*BoardInsight `json:"items"`
 }
 
@@ -30,7 +34,7 @@ type BoardInsight struct {
 
 	// Metric of how active the board is
 	// required: true
-	ActivityCount string `json:"activityCount"`
+	ActivityCount int `json:"activityCount"`
 
 	// IDs of users active on the board
 	// required: true
@@ -39,6 +43,45 @@ type BoardInsight struct {
 	// ID of user who created the board
 	// required: true
 	CreatedBy string `json:"createdBy"`
+
+	// The last activity time in miliseconds since the current epoch
+	// required: false
+	LastActivityAt int64 `json:"lastActivityAt,omitempty"`
+}
+
+// UnmarshalJSON decodes a BoardInsight accepting the activity count
+// either as a number or as a quoted number, as sent by the server.
+func (bi *BoardInsight) UnmarshalJSON(data []byte) error {
+	type boardInsightAlias BoardInsight
+	aux := struct {
+		*boardInsightAlias
+		ActivityCount json.Number `json:"activityCount"`
+	}{
+		boardInsightAlias: (*boardInsightAlias)(bi),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.ActivityCount == "" {
+		bi.ActivityCount = 0
+		return nil
+	}
+	count, err := aux.ActivityCount.Int64()
+	if err != nil {
+		return err
+	}
+	bi.ActivityCount = int(count)
+	return nil
+}
+
+// LastActivity returns the time of the last activity on the board, or
+// the zero time if the server didn't send it.
+func (bi *BoardInsight) LastActivity() time.Time {
+	if bi.LastActivityAt == 0 {
+		return time.Time{}
+	}
+	return GetTimeForMillis(bi.LastActivityAt)
 }
 
 func BoardInsightsFromJSON(data io.Reader) []BoardInsight {
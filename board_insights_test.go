@@ -0,0 +1,76 @@
+package boards
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testBoardInsightsPayload = `{
+	"has_next": true,
+	"items": [
+		{
+			"boardID": "board1",
+			"icon": "📋",
+			"title": "Roadmap",
+			"activityCount": "42",
+			"activeUsers": ["user1", "user2"],
+			"createdBy": "user1",
+			"lastActivityAt": 1700000000000
+		},
+		{
+			"boardID": "board2",
+			"title": "Bugs",
+			"activityCount": 7,
+			"activeUsers": ["user3"],
+			"createdBy": "user3"
+		}
+	]
+}`
+
+func TestGetTeamBoardsInsights(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testBoardInsightsPayload))
+	})
+
+	list, resp := client.GetTeamBoardsInsights("team1", "user1", "1_day", 0, 2)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if !list.HasNext {
+		t.Error("expected HasNext to be set")
+	}
+	if len(list.Items) != 2 {
+		t.Fatalf("expected 2 insights, got %d", len(list.Items))
+	}
+
+	first := list.Items[0]
+	if first.BoardID != "board1" || first.Title != "Roadmap" {
+		t.Errorf("unexpected insight %+v", first)
+	}
+	if first.ActivityCount != 42 {
+		t.Errorf("expected a quoted activity count of 42, got %d", first.ActivityCount)
+	}
+	if len(first.ActiveUsers) != 2 || first.ActiveUsers[1] != "user2" {
+		t.Errorf("unexpected active users %v", first.ActiveUsers)
+	}
+	if expected := time.UnixMilli(1700000000000); !first.LastActivity().Equal(expected) {
+		t.Errorf("expected last activity %s, got %s", expected, first.LastActivity())
+	}
+
+	second := list.Items[1]
+	if second.ActivityCount != 7 {
+		t.Errorf("expected a numeric activity count of 7, got %d", second.ActivityCount)
+	}
+	if !second.LastActivity().IsZero() {
+		t.Errorf("expected no last activity, got %s", second.LastActivity())
+	}
+}
+
+func TestBoardInsightUnmarshalJSONInvalidCount(t *testing.T) {
+	var insight BoardInsight
+	if err := insight.UnmarshalJSON([]byte(`{"boardID": "board1", "activityCount": "many"}`)); err == nil {
+		t.Error("expected an error for a non numeric activity count")
+	}
+}
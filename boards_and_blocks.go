@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"sort"
 )
 
 var ErrNoBoardsInBoardsAndBlocks = errors.New("at least one board is required")
@@ -21,6 +23,17 @@ func (e BlockDoesntBelongToAnyBoardErr) Error() string {
 	return fmt.Sprintf("block %s doesn't belong to any board", e.blockID)
 }
 
+// InvalidBlockInBoardsAndBlocksErr is returned by ValidateDeep with the
+// ID of the first block that failed validation.
+type InvalidBlockInBoardsAndBlocksErr struct {
+	BlockID string
+	reason  string
+}
+
+func (e InvalidBlockInBoardsAndBlocksErr) Error() string {
+	return fmt.Sprintf("block %s is invalid: %s", e.BlockID, e.reason)
+}
+
 // BoardsAndBlocks is used to operate over boards and blocks at the
 // same time
 // swagger:model
@@ -56,6 +69,74 @@ func (bab *BoardsAndBlocks) IsValid() error {
 	return nil
 }
 
+// ValidateDeep runs IsValid and additionally checks that every block has
+// a valid type, that every parent ID references a board or block present
+// in the payload, and that the content order of every card only
+// references blocks present in the payload.
+func (bab *BoardsAndBlocks) ValidateDeep() error {
+	if err := bab.IsValid(); err != nil {
+		return err
+	}
+
+	idsMap := map[string]bool{}
+	for _, board := range bab.Boards {
+		idsMap[board.ID] = true
+	}
+	for _, block := range bab.Blocks {
+		idsMap[block.ID] = true
+	}
+
+	for _, block := range bab.Blocks {
+		if _, err := BlockTypeFromString(string(block.Type)); err != nil {
+			return InvalidBlockInBoardsAndBlocksErr{block.ID, err.Error()}
+		}
+
+		if block.ParentID != "" && !idsMap[block.ParentID] {
+			return InvalidBlockInBoardsAndBlocksErr{block.ID, fmt.Sprintf("parent %s not found", block.ParentID)}
+		}
+
+		if block.Type != TypeCard {
+			continue
+		}
+
+		contentOrder, err := contentOrderIDs(block.Fields["contentOrder"])
+		if err != nil {
+			return InvalidBlockInBoardsAndBlocksErr{block.ID, err.Error()}
+		}
+		for _, id := range contentOrder {
+			if !idsMap[id] {
+				return InvalidBlockInBoardsAndBlocksErr{block.ID, fmt.Sprintf("content block %s not found", id)}
+			}
+		}
+	}
+	return nil
+}
+
+// contentOrderIDs flattens the contentOrder field of a card block. Entries
+// can either be block IDs or arrays of block IDs that are shown side by
+// side.
+func contentOrderIDs(contentOrder interface{}) ([]string, error) {
+	ids := []string{}
+	switch co := contentOrder.(type) {
+	case nil:
+	case string:
+		ids = append(ids, co)
+	case []string:
+		ids = append(ids, co...)
+	case []interface{}:
+		for _, item := range co {
+			itemIDs, err := contentOrderIDs(item)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, itemIDs...)
+		}
+	default:
+		return nil, ErrInvalidFieldType{"contentOrder"}
+	}
+	return ids, nil
+}
+
 // DeleteBoardsAndBlocks is used to list the boards and blocks to
 // delete on a request
 // swagger:model
@@ -103,6 +184,24 @@ func (dbab *PatchBoardsAndBlocks) IsValid() error {
 		return ErrBlockIDsAndPatchesMissmatchInBoardsAndBlocks
 	}
 
+	for _, patch := range dbab.BoardPatches {
+		if patch == nil {
+			return ErrEmptyPatch
+		}
+		if err := patch.IsValid(); err != nil {
+			return err
+		}
+	}
+
+	for _, patch := range dbab.BlockPatches {
+		if patch == nil {
+			return ErrEmptyPatch
+		}
+		if err := patch.IsValid(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -111,3 +210,256 @@ func BoardsAndBlocksFromJSON(data io.Reader) *BoardsAndBlocks {
 	_ = json.NewDecoder(data).Decode(&bab)
 	return bab
 }
+
+// DiffBoardsAndBlocks computes the changes needed to transform current
+// into desired. It returns the patches for the boards and blocks present
+// in both, the boards that only exist in current and need to be deleted,
+// the boards that only exist in desired and need to be created along with
+// their blocks, the new blocks of boards that are kept, and the blocks
+// that need to be deleted from boards that are kept. A nil current or
+// desired is handled as an empty BoardsAndBlocks.
+//
+// The server requires every block of a PatchBoardsAndBlocks, a
+// DeleteBoardsAndBlocks or a created BoardsAndBlocks to belong to one of
+// its boards. The board of every patched block is therefore listed in the
+// patch, with an empty BoardPatch if the board itself doesn't change. As
+// every board of a DeleteBoardsAndBlocks is deleted, the delete request
+// only lists the removed boards and their blocks. The new blocks of kept
+// boards are returned separately, to be created with InsertBlocks, and
+// the blocks removed from kept boards are returned separately too, to be
+// deleted with DeleteBlock. A block patch can't change the board of a
+// block, so a block moved to another board is deleted from its current
+// board and created again in the desired one.
+func DiffBoardsAndBlocks(current, desired *BoardsAndBlocks) (*PatchBoardsAndBlocks, *DeleteBoardsAndBlocks, *BoardsAndBlocks, []*Block, []*Block) {
+	if current == nil {
+		current = &BoardsAndBlocks{}
+	}
+	if desired == nil {
+		desired = &BoardsAndBlocks{}
+	}
+
+	patch := &PatchBoardsAndBlocks{
+		BoardIDs:     []string{},
+		BoardPatches: []*BoardPatch{},
+		BlockIDs:     []string{},
+		BlockPatches: []*BlockPatch{},
+	}
+	toDelete := &DeleteBoardsAndBlocks{
+		Boards: []string{},
+		Blocks: []string{},
+	}
+	toCreate := &BoardsAndBlocks{
+		Boards: []*Board{},
+		Blocks: []*Block{},
+	}
+	blocksToInsert := []*Block{}
+	blocksToDelete := []*Block{}
+
+	currentBoards := map[string]*Board{}
+	for _, board := range current.Boards {
+		currentBoards[board.ID] = board
+	}
+	desiredBoards := map[string]bool{}
+	createdBoards := map[string]bool{}
+	patchedBoards := map[string]bool{}
+	for _, board := range desired.Boards {
+		desiredBoards[board.ID] = true
+		currentBoard, ok := currentBoards[board.ID]
+		if !ok {
+			toCreate.Boards = append(toCreate.Boards, board)
+			createdBoards[board.ID] = true
+			continue
+		}
+		if boardPatch := diffBoards(currentBoard, board); boardPatch != nil {
+			patch.BoardIDs = append(patch.BoardIDs, board.ID)
+			patch.BoardPatches = append(patch.BoardPatches, boardPatch)
+			patchedBoards[board.ID] = true
+		}
+	}
+	deletedBoards := map[string]bool{}
+	for _, board := range current.Boards {
+		if !desiredBoards[board.ID] {
+			toDelete.Boards = append(toDelete.Boards, board.ID)
+			deletedBoards[board.ID] = true
+		}
+	}
+
+	currentBlocks := map[string]*Block{}
+	for _, block := range current.Blocks {
+		currentBlocks[block.ID] = block
+	}
+	keptBlocks := map[string]bool{}
+	for _, block := range desired.Blocks {
+		currentBlock, ok := currentBlocks[block.ID]
+		if !ok || currentBlock.BoardID != block.BoardID {
+			if createdBoards[block.BoardID] {
+				toCreate.Blocks = append(toCreate.Blocks, block)
+			} else {
+				blocksToInsert = append(blocksToInsert, block)
+			}
+			continue
+		}
+
+		keptBlocks[block.ID] = true
+		if blockPatch := diffBlocks(currentBlock, block); blockPatch != nil {
+			patch.BlockIDs = append(patch.BlockIDs, block.ID)
+			patch.BlockPatches = append(patch.BlockPatches, blockPatch)
+			if !patchedBoards[block.BoardID] {
+				patch.BoardIDs = append(patch.BoardIDs, block.BoardID)
+				patch.BoardPatches = append(patch.BoardPatches, &BoardPatch{})
+				patchedBoards[block.BoardID] = true
+			}
+		}
+	}
+	for _, block := range current.Blocks {
+		if keptBlocks[block.ID] {
+			continue
+		}
+		if deletedBoards[block.BoardID] {
+			toDelete.Blocks = append(toDelete.Blocks, block.ID)
+		} else {
+			blocksToDelete = append(blocksToDelete, block)
+		}
+	}
+
+	return patch, toDelete, toCreate, blocksToInsert, blocksToDelete
+}
+
+// diffBoards returns the patch that transforms current into desired, or
+// nil if both boards are equivalent.
+func diffBoards(current, desired *Board) *BoardPatch {
+	p := &BoardPatch{}
+	changed := false
+
+	if current.Type != desired.Type {
+		p.Type = &desired.Type
+		changed = true
+	}
+	if current.MinimumRole != desired.MinimumRole {
+		p.MinimumRole = &desired.MinimumRole
+		changed = true
+	}
+	if current.Title != desired.Title {
+		p.Title = &desired.Title
+		changed = true
+	}
+	if current.Description != desired.Description {
+		p.Description = &desired.Description
+		changed = true
+	}
+	if current.Icon != desired.Icon {
+		p.Icon = &desired.Icon
+		changed = true
+	}
+	if current.ShowDescription != desired.ShowDescription {
+		p.ShowDescription = &desired.ShowDescription
+		changed = true
+	}
+	if current.ChannelID != desired.ChannelID {
+		p.ChannelID = &desired.ChannelID
+		changed = true
+	}
+
+	updated, deleted := diffMaps(current.Properties, desired.Properties)
+	if len(updated) != 0 {
+		p.UpdatedProperties = updated
+		changed = true
+	}
+	if len(deleted) != 0 {
+		p.DeletedProperties = deleted
+		changed = true
+	}
+
+	currentCardProperties := map[string]map[string]interface{}{}
+	for _, prop := range current.CardProperties {
+		if id, ok := prop["id"].(string); ok {
+			currentCardProperties[id] = prop
+		}
+	}
+	desiredCardProperties := map[string]bool{}
+	for _, prop := range desired.CardProperties {
+		id, ok := prop["id"].(string)
+		if !ok {
+			// bad property, skipping
+			continue
+		}
+		desiredCardProperties[id] = true
+		if !reflect.DeepEqual(currentCardProperties[id], prop) {
+			p.UpdatedCardProperties = append(p.UpdatedCardProperties, prop)
+			changed = true
+		}
+	}
+	for _, prop := range current.CardProperties {
+		if id, ok := prop["id"].(string); ok && !desiredCardProperties[id] {
+			p.DeletedCardProperties = append(p.DeletedCardProperties, id)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return p
+}
+
+// diffBlocks returns the patch that transforms current into desired, or
+// nil if both blocks are equivalent. The content order of cards is stored
+// in the block fields, so it is compared as part of them.
+func diffBlocks(current, desired *Block) *BlockPatch {
+	p := &BlockPatch{}
+	changed := false
+
+	if current.ParentID != desired.ParentID {
+		p.ParentID = &desired.ParentID
+		changed = true
+	}
+	if current.Schema != desired.Schema {
+		p.Schema = &desired.Schema
+		changed = true
+	}
+	if current.Type != desired.Type {
+		p.Type = &desired.Type
+		changed = true
+	}
+	if current.Title != desired.Title {
+		p.Title = &desired.Title
+		changed = true
+	}
+
+	updated, deleted := diffMaps(current.Fields, desired.Fields)
+	if len(updated) != 0 {
+		p.UpdatedFields = updated
+		changed = true
+	}
+	if len(deleted) != 0 {
+		p.DeletedFields = deleted
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return p
+}
+
+// diffMaps returns the entries of desired that are new or differ from
+// current, and the sorted keys of current that are missing in desired.
+func diffMaps(current, desired map[string]interface{}) (map[string]interface{}, []string) {
+	updated := map[string]interface{}{}
+	for key, value := range desired {
+		currentValue, ok := current[key]
+		if !ok || !reflect.DeepEqual(currentValue, value) {
+			updated[key] = value
+		}
+	}
+
+	deleted := []string{}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			deleted = append(deleted, key)
+		}
+	}
+	sort.Strings(deleted)
+
+	return updated, deleted
+}
@@ -0,0 +1,235 @@
+package boards
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func newTestBoardsAndBlocks() *BoardsAndBlocks {
+	return &BoardsAndBlocks{
+		Boards: []*Board{{ID: "board1"}},
+		Blocks: []*Block{
+			{
+				ID:       "card1",
+				BoardID:  "board1",
+				ParentID: "board1",
+				Type:     TypeCard,
+				Fields: map[string]interface{}{
+					"contentOrder": []interface{}{"text1", []interface{}{"text2"}},
+				},
+			},
+			{ID: "text1", BoardID: "board1", ParentID: "card1", Type: TypeText},
+			{ID: "text2", BoardID: "board1", ParentID: "card1", Type: TypeText},
+		},
+	}
+}
+
+func TestBoardsAndBlocksValidateDeep(t *testing.T) {
+	t.Run("valid payload", func(t *testing.T) {
+		if err := newTestBoardsAndBlocks().ValidateDeep(); err != nil {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("runs the shallow validation first", func(t *testing.T) {
+		bab := newTestBoardsAndBlocks()
+		bab.Blocks[1].BoardID = "board2"
+
+		var errBoard BlockDoesntBelongToAnyBoardErr
+		if err := bab.ValidateDeep(); !errors.As(err, &errBoard) {
+			t.Errorf("expected a BlockDoesntBelongToAnyBoardErr, got %v", err)
+		}
+	})
+
+	testCases := []struct {
+		name            string
+		mutate          func(bab *BoardsAndBlocks)
+		expectedBlockID string
+	}{
+		{
+			name:            "invalid block type",
+			mutate:          func(bab *BoardsAndBlocks) { bab.Blocks[1].Type = "unknown" },
+			expectedBlockID: "text1",
+		},
+		{
+			name:            "orphan parent",
+			mutate:          func(bab *BoardsAndBlocks) { bab.Blocks[2].ParentID = "card2" },
+			expectedBlockID: "text2",
+		},
+		{
+			name: "dangling content order",
+			mutate: func(bab *BoardsAndBlocks) {
+				bab.Blocks[0].Fields["contentOrder"] = []interface{}{"text1", []interface{}{"text2", "text3"}}
+			},
+			expectedBlockID: "card1",
+		},
+		{
+			name:            "invalid content order",
+			mutate:          func(bab *BoardsAndBlocks) { bab.Blocks[0].Fields["contentOrder"] = 42 },
+			expectedBlockID: "card1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bab := newTestBoardsAndBlocks()
+			tc.mutate(bab)
+
+			var errBlock InvalidBlockInBoardsAndBlocksErr
+			if err := bab.ValidateDeep(); !errors.As(err, &errBlock) {
+				t.Fatalf("expected an InvalidBlockInBoardsAndBlocksErr, got %v", err)
+			}
+			if errBlock.BlockID != tc.expectedBlockID {
+				t.Errorf("expected block %s to be reported, got %s", tc.expectedBlockID, errBlock.BlockID)
+			}
+		})
+	}
+}
+
+func TestDiffBoardsAndBlocks(t *testing.T) {
+	current := &BoardsAndBlocks{
+		Boards: []*Board{
+			{ID: "board1", Title: "Board 1", Properties: map[string]interface{}{}},
+			{ID: "board2", Title: "Board 2", Properties: map[string]interface{}{}},
+			{ID: "board3", Title: "Board 3", Properties: map[string]interface{}{}},
+		},
+		Blocks: []*Block{
+			{ID: "card1", BoardID: "board1", Type: TypeCard, Title: "Card 1", Fields: map[string]interface{}{"contentOrder": []interface{}{"text1"}}},
+			{ID: "text1", BoardID: "board1", Type: TypeText, Title: "Text 1"},
+			{ID: "card2", BoardID: "board2", Type: TypeCard, Title: "Card 2", Fields: map[string]interface{}{"icon": "a"}},
+			{ID: "card3", BoardID: "board2", Type: TypeCard, Title: "Card 3"},
+			{ID: "card4", BoardID: "board3", Type: TypeCard, Title: "Card 4"},
+			{ID: "card6", BoardID: "board2", Type: TypeCard, Title: "Card 6"},
+		},
+	}
+	desired := &BoardsAndBlocks{
+		Boards: []*Board{
+			{ID: "board1", Title: "Board 1 renamed", Properties: map[string]interface{}{}},
+			{ID: "board2", Title: "Board 2", Properties: map[string]interface{}{}},
+			{ID: "board4", Title: "Board 4"},
+		},
+		Blocks: []*Block{
+			{ID: "card1", BoardID: "board1", Type: TypeCard, Title: "Card 1", Fields: map[string]interface{}{"contentOrder": []interface{}{"text2", "text1"}}},
+			{ID: "text1", BoardID: "board1", Type: TypeText, Title: "Text 1"},
+			{ID: "text2", BoardID: "board1", Type: TypeText, Title: "Text 2"},
+			{ID: "card2", BoardID: "board2", Type: TypeCard, Title: "Card 2 renamed", Fields: map[string]interface{}{}},
+			{ID: "card5", BoardID: "board4", Type: TypeCard, Title: "Card 5"},
+			{ID: "card6", BoardID: "board1", Type: TypeCard, Title: "Card 6"},
+		},
+	}
+
+	patch, toDelete, toCreate, blocksToInsert, blocksToDelete := DiffBoardsAndBlocks(current, desired)
+
+	t.Run("patches", func(t *testing.T) {
+		expectedBoardIDs := []string{"board1", "board2"}
+		if !reflect.DeepEqual(patch.BoardIDs, expectedBoardIDs) {
+			t.Fatalf("expected patched boards %v, got %v", expectedBoardIDs, patch.BoardIDs)
+		}
+		if title := patch.BoardPatches[0].Title; title == nil || *title != "Board 1 renamed" {
+			t.Errorf("expected board1 to be renamed, got %v", title)
+		}
+		if !reflect.DeepEqual(patch.BoardPatches[1], &BoardPatch{}) {
+			t.Errorf("expected an empty patch for board2, got %+v", patch.BoardPatches[1])
+		}
+
+		expectedBlockIDs := []string{"card1", "card2"}
+		if !reflect.DeepEqual(patch.BlockIDs, expectedBlockIDs) {
+			t.Fatalf("expected patched blocks %v, got %v", expectedBlockIDs, patch.BlockIDs)
+		}
+		expectedFields := map[string]interface{}{"contentOrder": []interface{}{"text2", "text1"}}
+		if !reflect.DeepEqual(patch.BlockPatches[0].UpdatedFields, expectedFields) {
+			t.Errorf("expected the content order to be updated, got %v", patch.BlockPatches[0].UpdatedFields)
+		}
+		cardPatch := patch.BlockPatches[1]
+		if cardPatch.Title == nil || *cardPatch.Title != "Card 2 renamed" {
+			t.Errorf("expected card2 to be renamed, got %v", cardPatch.Title)
+		}
+		if !reflect.DeepEqual(cardPatch.DeletedFields, []string{"icon"}) {
+			t.Errorf("expected the icon field to be deleted, got %v", cardPatch.DeletedFields)
+		}
+
+		if err := patch.IsValid(); err != nil {
+			t.Errorf("expected a valid patch, got %s", err)
+		}
+	})
+
+	t.Run("deletes", func(t *testing.T) {
+		if !reflect.DeepEqual(toDelete.Boards, []string{"board3"}) {
+			t.Errorf("expected board3 to be deleted, got %v", toDelete.Boards)
+		}
+		if !reflect.DeepEqual(toDelete.Blocks, []string{"card4"}) {
+			t.Errorf("expected card4 to be deleted with its board, got %v", toDelete.Blocks)
+		}
+		if ids := blockIDs(blocksToDelete); !reflect.DeepEqual(ids, []string{"card3", "card6"}) {
+			t.Errorf("expected card3 and the moved card6 to be deleted from board2, got %v", ids)
+		}
+	})
+
+	t.Run("creates", func(t *testing.T) {
+		if len(toCreate.Boards) != 1 || toCreate.Boards[0].ID != "board4" {
+			t.Errorf("expected board4 to be created, got %+v", toCreate.Boards)
+		}
+		if ids := blockIDs(toCreate.Blocks); !reflect.DeepEqual(ids, []string{"card5"}) {
+			t.Errorf("expected card5 to be created with board4, got %v", ids)
+		}
+		if err := toCreate.IsValid(); err != nil {
+			t.Errorf("expected the created boards and blocks to be valid, got %s", err)
+		}
+	})
+
+	t.Run("inserts", func(t *testing.T) {
+		if ids := blockIDs(blocksToInsert); !reflect.DeepEqual(ids, []string{"text2", "card6"}) {
+			t.Errorf("expected text2 and the moved card6 to be inserted, got %v", ids)
+		}
+		for _, block := range blocksToInsert {
+			if block.BoardID != "board1" {
+				t.Errorf("expected block %s to be inserted in board1, got %s", block.ID, block.BoardID)
+			}
+		}
+	})
+
+	t.Run("no changes", func(t *testing.T) {
+		patch, toDelete, toCreate, blocksToInsert, blocksToDelete := DiffBoardsAndBlocks(current, current)
+		if len(patch.BoardIDs) != 0 || len(patch.BlockIDs) != 0 {
+			t.Errorf("expected no patches, got %+v", patch)
+		}
+		if len(toDelete.Boards) != 0 || len(toDelete.Blocks) != 0 || len(blocksToDelete) != 0 {
+			t.Errorf("expected no deletions, got %+v and %+v", toDelete, blocksToDelete)
+		}
+		if len(toCreate.Boards) != 0 || len(toCreate.Blocks) != 0 || len(blocksToInsert) != 0 {
+			t.Errorf("expected no creations, got %+v and %+v", toCreate, blocksToInsert)
+		}
+	})
+
+	t.Run("nil inputs", func(t *testing.T) {
+		patch, toDelete, toCreate, blocksToInsert, blocksToDelete := DiffBoardsAndBlocks(nil, desired)
+		if len(patch.BoardIDs) != 0 || len(toDelete.Boards) != 0 || len(blocksToDelete) != 0 {
+			t.Errorf("expected no patches nor deletions, got %+v, %+v and %+v", patch, toDelete, blocksToDelete)
+		}
+		if len(toCreate.Boards) != 3 || len(toCreate.Blocks) != 6 || len(blocksToInsert) != 0 {
+			t.Errorf("expected everything to be created, got %+v and %+v", toCreate, blocksToInsert)
+		}
+
+		patch, toDelete, toCreate, blocksToInsert, blocksToDelete = DiffBoardsAndBlocks(current, nil)
+		if len(patch.BoardIDs) != 0 || len(toCreate.Boards) != 0 || len(blocksToInsert) != 0 || len(blocksToDelete) != 0 {
+			t.Errorf("expected only deletions, got %+v, %+v, %+v and %+v", patch, toCreate, blocksToInsert, blocksToDelete)
+		}
+		if len(toDelete.Boards) != 3 || len(toDelete.Blocks) != 6 {
+			t.Errorf("expected everything to be deleted, got %+v", toDelete)
+		}
+
+		patch, toDelete, toCreate, blocksToInsert, blocksToDelete = DiffBoardsAndBlocks(nil, nil)
+		if len(patch.BoardIDs) != 0 || len(toDelete.Boards) != 0 || len(toCreate.Boards) != 0 || len(blocksToInsert) != 0 || len(blocksToDelete) != 0 {
+			t.Error("expected no changes")
+		}
+	})
+}
+
+func blockIDs(blocks []*Block) []string {
+	ids := []string{}
+	for _, block := range blocks {
+		ids = append(ids, block.ID)
+	}
+	return ids
+}
@@ -3,6 +3,7 @@ package boards
 import (
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/rivo/uniseg"
 )
@@ -141,19 +142,19 @@ func (c *Card) CheckValid() error {
 type CardPatch struct {
 	// The display title
 	// required: false
-	Title *string `json:"title"`
+	Title *string `json:"title,omitempty"`
 
 	// An array of content block ids specifying the ordering of content for this card.
 	// required: false
-	ContentOrder *[]string `json:"contentOrder"`
+	ContentOrder *[]string `json:"contentOrder,omitempty"`
 
 	// The icon of the card
 	// required: false
-	Icon *string `json:"icon"`
+	Icon *string `json:"icon,omitempty"`
 
 	// A map of property ids to property option ids to be updated
 	// required: false
-	UpdatedProperties map[string]any `json:"updatedProperties"`
+	UpdatedProperties map[string]any `json:"updatedProperties,omitempty"`
 }
 
 // Patch returns an updated version of the card.
@@ -191,6 +192,39 @@ func (p *CardPatch) CheckValid() error {
 	return nil
 }
 
+// OrderContentBlocks returns the blocks that are children of the card,
+// following the card's ContentOrder. Children not referenced by the
+// content order, like comments, are appended sorted by creation time.
+func OrderContentBlocks(card *Card, blocks []*Block) []*Block {
+	children := map[string]*Block{}
+	for _, block := range blocks {
+		if block.ParentID == card.ID {
+			children[block.ID] = block
+		}
+	}
+
+	ordered := make([]*Block, 0, len(children))
+	for _, id := range card.ContentOrder {
+		if block, ok := children[id]; ok {
+			ordered = append(ordered, block)
+			delete(children, id)
+		}
+	}
+
+	rest := make([]*Block, 0, len(children))
+	for _, block := range children {
+		rest = append(rest, block)
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].CreateAt == rest[j].CreateAt {
+			return rest[i].ID < rest[j].ID
+		}
+		return rest[i].CreateAt < rest[j].CreateAt
+	})
+
+	return append(ordered, rest...)
+}
+
 // Card2Block converts a card to block using a shallow copy. Not needed once cards are first class entities.
 func Card2Block(card *Card) *Block {
 	fields := make(map[string]interface{})
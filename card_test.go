@@ -0,0 +1,26 @@
+package boards
+
+import "testing"
+
+func TestOrderContentBlocks(t *testing.T) {
+	card := &Card{ID: "card1", ContentOrder: []string{"text2", "missing", "text1"}}
+	blocks := []*Block{
+		{ID: "text1", ParentID: "card1", Type: TypeText},
+		{ID: "comment2", ParentID: "card1", Type: TypeComment, CreateAt: 20},
+		{ID: "text2", ParentID: "card1", Type: TypeText},
+		{ID: "comment1", ParentID: "card1", Type: TypeComment, CreateAt: 10},
+		{ID: "text3", ParentID: "card2", Type: TypeText},
+	}
+
+	ordered := OrderContentBlocks(card, blocks)
+
+	expected := []string{"text2", "text1", "comment1", "comment2"}
+	if len(ordered) != len(expected) {
+		t.Fatalf("expected %d blocks, got %d", len(expected), len(ordered))
+	}
+	for i, id := range expected {
+		if ordered[i].ID != id {
+			t.Errorf("expected block %d to be %s, got %s", i, id, ordered[i].ID)
+		}
+	}
+}
@@ -12,6 +12,14 @@ const (
 	CategoryTypeCustom = "custom"
 )
 
+// DefaultCategoryName is the name of the system category that holds the
+// boards that haven't been assigned to any other category.
+const DefaultCategoryName = "Boards"
+
+// FavoritesCategoryName is the name of the system category that holds the
+// user's favorite boards.
+const FavoritesCategoryName = "Favorites"
+
 // Category is a board category
 // swagger:model
 type Category struct {
@@ -1,15 +1,27 @@
 package boards
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
-	APIURLSuffix = "/api/v2"
+	APIURLSuffix      = "/api/v2"
+	PluginBasePath    = "/plugins/focalboard"
+	DefaultAuthScheme = "Bearer"
 )
 
 type RequestReaderError struct {
@@ -20,13 +32,37 @@ func (rre RequestReaderError) Error() string {
 	return "payload: " + string(rre.buf)
 }
 
+// errorResponseFromError extracts the ErrorResponse sent by the server
+// from an error returned by doAPIRequestReader, or nil if there is none.
+func errorResponseFromError(err error) *ErrorResponse {
+	var rre RequestReaderError
+	if !errors.As(err, &rre) {
+		return nil
+	}
+
+	var errResp ErrorResponse
+	if jsonErr := json.Unmarshal(rre.buf, &errResp); jsonErr != nil {
+		return nil
+	}
+	return &errResp
+}
+
 type Response struct {
 	StatusCode int
 	Error      error
 	Header     http.Header
+	// ClientFiltered is set when the results were filtered by the client
+	// instead of the server.
+	ClientFiltered bool
+	// Warnings contains non fatal issues detected with the request.
+	Warnings []string
 }
 
 func BuildResponse(r *http.Response) *Response {
+	if r == nil {
+		return &Response{}
+	}
+
 	return &Response{
 		StatusCode: r.StatusCode,
 		Header:     r.Header,
@@ -48,6 +84,74 @@ func BuildErrorResponse(r *http.Response, err error) *Response {
 	}
 }
 
+// DecodeError is returned when a response body can't be decoded. It
+// names the endpoint and the type being decoded, and retains the raw
+// body to help diagnosing the failure.
+type DecodeError struct {
+	Endpoint string
+	Target   string
+	Body     []byte
+	Err      error
+}
+
+func (de *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s from %s: %s", de.Target, de.Endpoint, de.Err)
+}
+
+func (de *DecodeError) Unwrap() error {
+	return de.Err
+}
+
+// decodeJSONBody decodes the response body into v, decompressing it first
+// if the server sent it gzipped and the transport didn't already do it.
+func decodeJSONBody(r *http.Response, v interface{}) error {
+	return decodeJSONBodyWithOptions(r, v, false)
+}
+
+// decodeOptionalJSONBody works like decodeJSONBody, but an empty body is
+// not an error and leaves v untouched.
+func decodeOptionalJSONBody(r *http.Response, v interface{}) error {
+	return decodeJSONBodyWithOptions(r, v, true)
+}
+
+func decodeJSONBodyWithOptions(r *http.Response, v interface{}, allowEmpty bool) error {
+	newDecodeError := func(body []byte, err error) *DecodeError {
+		de := &DecodeError{
+			Target: strings.ReplaceAll(reflect.TypeOf(v).Elem().String(), "boards.", ""),
+			Body:   body,
+			Err:    err,
+		}
+		if r.Request != nil {
+			de.Endpoint = r.Request.Method + " " + r.Request.URL.Path
+		}
+		return de
+	}
+
+	body := r.Body
+	if !r.Uncompressed && strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return newDecodeError(nil, err)
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return newDecodeError(b, err)
+	}
+
+	if allowEmpty && len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return newDecodeError(b, err)
+	}
+	return nil
+}
+
 func closeBody(r *http.Response) {
 	if r.Body != nil {
 		_, _ = io.Copy(io.Discard, r.Body)
@@ -55,6 +159,29 @@ func closeBody(r *http.Response) {
 	}
 }
 
+// patchToJSON validates a patch with its validation method, e.g. IsValid
+// or CardPatch.CheckValid, and encodes it. Unset fields are omitted, so a
+// patch that doesn't set any field, usually a zero value passed by
+// mistake, is rejected with ErrEmptyPatch instead of being sent.
+func patchToJSON(patch interface{}, validate func() error) (string, error) {
+	if v := reflect.ValueOf(patch); !v.IsValid() || (v.Kind() == reflect.Pointer && v.IsNil()) {
+		return "", ErrEmptyPatch
+	}
+
+	if err := validate(); err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return "", err
+	}
+	if string(b) == "{}" {
+		return "", ErrEmptyPatch
+	}
+	return string(b), nil
+}
+
 func toJSON(v interface{}) string {
 	b, _ := json.Marshal(v)
 	return string(b)
@@ -67,46 +194,198 @@ type Client struct {
 	HTTPHeader map[string]string
 	// Token if token is empty indicate client is not login yet
 	Token string
+
+	ctx        context.Context
+	authScheme *string
+}
+
+// ClientOption configures a Client when passed to NewClient.
+type ClientOption func(c *Client)
+
+// WithBasePath mounts the API under the given path prefix, e.g.
+// PluginBasePath when Boards runs as a Mattermost plugin. The prefix is
+// not added again if the client URL already ends with it.
+func WithBasePath(prefix string) ClientOption {
+	return func(c *Client) {
+		prefix = strings.Trim(prefix, "/")
+		baseURL := c.URL
+		if prefix != "" && !strings.HasSuffix(baseURL, "/"+prefix) {
+			baseURL += "/" + prefix
+		}
+		c.APIURL = baseURL + APIURLSuffix
+	}
+}
+
+// WithDefaultHeaders replaces the headers sent with every request,
+// including the default X-Requested-With header.
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		c.HTTPHeader = make(map[string]string, len(headers))
+		for k, v := range headers {
+			c.HTTPHeader[k] = v
+		}
+	}
+}
+
+// WithoutXRequestedWith removes the default X-Requested-With header, which
+// some firewalls and server to server integrations reject.
+func WithoutXRequestedWith() ClientOption {
+	return func(c *Client) {
+		delete(c.HTTPHeader, "X-Requested-With")
+	}
 }
 
-func NewClient(url, sessionToken string) *Client {
+// WithDefaultContext sets the context used for every request that isn't
+// given one with WithContext. Cancelling it aborts all the in-flight
+// requests of the client.
+func WithDefaultContext(ctx context.Context) ClientOption {
+	return func(c *Client) {
+		c.ctx = ctx
+	}
+}
+
+// WithAuthScheme sets the scheme used in the Authorization header, which
+// defaults to DefaultAuthScheme. An empty scheme sends the bare token.
+func WithAuthScheme(scheme string) ClientOption {
+	return func(c *Client) {
+		c.authScheme = &scheme
+	}
+}
+
+func NewClient(url, sessionToken string, opts ...ClientOption) *Client {
 	url = strings.TrimRight(url, "/")
 
 	headers := map[string]string{
 		"X-Requested-With": "XMLHttpRequest",
 	}
 
-	return &Client{url, url + APIURLSuffix, &http.Client{}, headers, sessionToken}
+	c := &Client{
+		URL:        url,
+		APIURL:     url + APIURLSuffix,
+		HTTPClient: &http.Client{},
+		HTTPHeader: headers,
+		Token:      sessionToken,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+func (c *Client) DoAPIGet(url, etag string, opts ...RequestOption) (*http.Response, error) {
+	return c.DoAPIRequest(http.MethodGet, c.APIURL+url, "", etag, opts...)
+}
+
+func (c *Client) DoAPIPost(url, data string, opts ...RequestOption) (*http.Response, error) {
+	return c.DoAPIRequest(http.MethodPost, c.APIURL+url, data, "", opts...)
+}
+
+func (c *Client) DoAPIPatch(url, data string, opts ...RequestOption) (*http.Response, error) {
+	return c.DoAPIRequest(http.MethodPatch, c.APIURL+url, data, "", opts...)
 }
 
-func (c *Client) DoAPIGet(url, etag string) (*http.Response, error) {
-	return c.DoAPIRequest(http.MethodGet, c.APIURL+url, "", etag)
+func (c *Client) DoAPIPut(url, data string, opts ...RequestOption) (*http.Response, error) {
+	return c.DoAPIRequest(http.MethodPut, c.APIURL+url, data, "", opts...)
 }
 
-func (c *Client) DoAPIPost(url, data string) (*http.Response, error) {
-	return c.DoAPIRequest(http.MethodPost, c.APIURL+url, data, "")
+func (c *Client) DoAPIDelete(url string, data string, opts ...RequestOption) (*http.Response, error) {
+	return c.DoAPIRequest(http.MethodDelete, c.APIURL+url, data, "", opts...)
 }
 
-func (c *Client) DoAPIPatch(url, data string) (*http.Response, error) {
-	return c.DoAPIRequest(http.MethodPatch, c.APIURL+url, data, "")
+func (c *Client) DoAPIRequest(method, url, data, etag string, opts ...RequestOption) (*http.Response, error) {
+	return c.doAPIRequestReader(method, url, strings.NewReader(data), etag, opts...)
+}
+
+// RequestOption modifies a single request before it is sent.
+type RequestOption func(r *http.Request)
+
+// WithContext sets the context of a single request, taking precedence
+// over the client's default context.
+func WithContext(ctx context.Context) RequestOption {
+	return func(r *http.Request) {
+		*r = *r.WithContext(ctx)
+	}
 }
 
-func (c *Client) DoAPIPut(url, data string) (*http.Response, error) {
-	return c.DoAPIRequest(http.MethodPut, c.APIURL+url, data, "")
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy configures how transient failures, i.e. network errors,
+// rate limiting and server errors, are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one
+	MaxAttempts int
+
+	// Interval is the wait before the first retry, doubled on each
+	// following retry
+	Interval time.Duration
 }
 
-func (c *Client) DoAPIDelete(url string, data string) (*http.Response, error) {
-	return c.DoAPIRequest(http.MethodDelete, c.APIURL+url, data, "")
+// DefaultRetryPolicy is the policy used to retry requests carrying an
+// idempotency key and the operations of a BatchExecutor.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Interval:    100 * time.Millisecond,
 }
 
-func (c *Client) DoAPIRequest(method, url, data, etag string) (*http.Response, error) {
-	return c.doAPIRequestReader(method, url, strings.NewReader(data), etag)
+// retry calls attempt until it returns false, meaning that it didn't fail
+// transiently, or MaxAttempts is reached. It returns the context error if
+// ctx is done while waiting for the next attempt.
+func (p RetryPolicy) retry(ctx context.Context, attempt func() bool) error {
+	interval := p.Interval
+	for n := 1; attempt() && n < p.MaxAttempts; n++ {
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		interval *= 2
+	}
+	return nil
 }
 
-type requestOption func(r *http.Request)
+// WithIdempotencyKey sets the Idempotency-Key header on a request.
+// Requests carrying the header are retried on network errors, rate
+// limiting and server errors following DefaultRetryPolicy, sending the
+// same key on every attempt. Reusing the key across retries
+// is always safe, but duplicates are only prevented if the server
+// supports the header.
+func WithIdempotencyKey(key string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(idempotencyKeyHeader, key)
+	}
+}
 
-func (c *Client) doAPIRequestReader(method, url string, data io.Reader, _ /* etag */ string, opts ...requestOption) (*http.Response, error) {
-	rq, err := http.NewRequest(method, url, data)
+// cleanURLPath collapses duplicate slashes in the path of a URL, leaving
+// the scheme separator and the query string untouched.
+func cleanURLPath(rawURL string) string {
+	pathStart := 0
+	if i := strings.Index(rawURL, "://"); i != -1 && !strings.ContainsAny(rawURL[:i], "/?#") {
+		pathStart = i + len("://")
+	}
+	pathEnd := len(rawURL)
+	if i := strings.IndexAny(rawURL[pathStart:], "?#"); i != -1 {
+		pathEnd = pathStart + i
+	}
+
+	path := rawURL[pathStart:pathEnd]
+	for strings.Contains(path, "//") {
+		path = strings.ReplaceAll(path, "//", "/")
+	}
+	return rawURL[:pathStart] + path + rawURL[pathEnd:]
+}
+
+func (c *Client) doAPIRequestReader(method, url string, data io.Reader, _ /* etag */ string, opts ...RequestOption) (*http.Response, error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	rq, err := http.NewRequestWithContext(ctx, method, cleanURLPath(url), data)
 	if err != nil {
 		return nil, err
 	}
@@ -122,10 +401,18 @@ func (c *Client) doAPIRequestReader(method, url string, data io.Reader, _ /* eta
 	}
 
 	if c.Token != "" {
-		rq.Header.Set("Authorization", "Bearer "+c.Token)
+		authScheme := DefaultAuthScheme
+		if c.authScheme != nil {
+			authScheme = *c.authScheme
+		}
+		if authScheme != "" {
+			rq.Header.Set("Authorization", authScheme+" "+c.Token)
+		} else {
+			rq.Header.Set("Authorization", c.Token)
+		}
 	}
 
-	rp, err := c.HTTPClient.Do(rq)
+	rp, err := c.doWithRetries(rq)
 	if err != nil || rp == nil {
 		return nil, err
 	}
@@ -146,6 +433,78 @@ func (c *Client) doAPIRequestReader(method, url string, data io.Reader, _ /* eta
 	return rp, nil
 }
 
+// doWithRetries sends the request, retrying it if it carries an
+// idempotency key and failed transiently. Requests whose body can't be
+// rewound are sent only once.
+func (c *Client) doWithRetries(rq *http.Request) (*http.Response, error) {
+	if rq.Header.Get(idempotencyKeyHeader) == "" || (rq.Body != nil && rq.GetBody == nil) {
+		return c.HTTPClient.Do(rq)
+	}
+
+	ctx := rq.Context()
+	var rp *http.Response
+	var err error
+	retryErr := DefaultRetryPolicy.retry(ctx, func() bool {
+		attempt := rq
+		if rp != nil || err != nil {
+			if rp != nil {
+				closeBody(rp)
+			}
+			attempt = rq.Clone(ctx)
+			if rq.GetBody != nil {
+				body, bodyErr := rq.GetBody()
+				if bodyErr != nil {
+					rp, err = nil, bodyErr
+					return false
+				}
+				attempt.Body = body
+			}
+		}
+
+		rp, err = c.HTTPClient.Do(attempt)
+		statusCode := 0
+		if rp != nil {
+			statusCode = rp.StatusCode
+		}
+		return isTransientFailure(ctx, statusCode, err)
+	})
+	if retryErr != nil {
+		if rp != nil {
+			closeBody(rp)
+		}
+		return nil, retryErr
+	}
+	return rp, err
+}
+
+// isTransientFailure returns true if a request failed because of a
+// network error, rate limiting or a server error. Errors without a status
+// code that don't come from the network, e.g. validation errors returned
+// before sending the request, and cancellations are not transient.
+func isTransientFailure(ctx context.Context, statusCode int, err error) bool {
+	if statusCode == 0 {
+		if err == nil || ctx.Err() != nil {
+			return false
+		}
+		var errURL *url.Error
+		var errNet net.Error
+		return errors.As(err, &errURL) || errors.As(err, &errNet)
+	}
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// GetRaw sends a GET request to the given API path and returns the live
+// http.Response, so its body can be consumed as a stream. The body is not
+// closed: callers must close it when the response error is nil.
+func (c *Client) GetRaw(path string, opts ...RequestOption) (*http.Response, *Response) {
+	r, err := c.doAPIRequestReader(http.MethodGet, c.APIURL+path, nil, "", opts...)
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+
+	return r, BuildResponse(r)
+}
+
 func (c *Client) GetTeamRoute(teamID string) string {
 	return fmt.Sprintf("%s/%s", c.GetTeamsRoute(), teamID)
 }
@@ -217,8 +576,8 @@ func (c *Client) GetTeamBoardsInsights(teamID string, userID string, timeRange s
 	defer closeBody(r)
 
 	var boardInsightsList *BoardInsightsList
-	if jsonErr := json.NewDecoder(r.Body).Decode(&boardInsightsList); jsonErr != nil {
-		return nil, BuildErrorResponse(r, jsonErr)
+	if err := decodeJSONBody(r, &boardInsightsList); err != nil {
+		return nil, BuildErrorResponse(r, err)
 	}
 	return boardInsightsList, BuildResponse(r)
 }
@@ -232,8 +591,8 @@ func (c *Client) GetUserBoardsInsights(teamID string, userID string, timeRange s
 	defer closeBody(r)
 
 	var boardInsightsList *BoardInsightsList
-	if jsonErr := json.NewDecoder(r.Body).Decode(&boardInsightsList); jsonErr != nil {
-		return nil, BuildErrorResponse(r, jsonErr)
+	if err := decodeJSONBody(r, &boardInsightsList); err != nil {
+		return nil, BuildErrorResponse(r, err)
 	}
 	return boardInsightsList, BuildResponse(r)
 }
@@ -248,6 +607,45 @@ func (c *Client) GetBlocksForBoard(boardID string) ([]*Block, *Response) {
 	return BlocksFromJSON(r.Body), BuildResponse(r)
 }
 
+func (c *Client) GetBlocksForBoardByType(boardID string, blockType BlockType) ([]*Block, *Response) {
+	blockType, err := BlockTypeFromString(string(blockType))
+	if err != nil {
+		return nil, &Response{Error: err}
+	}
+
+	r, err := c.DoAPIGet(c.GetBlocksRoute(boardID)+"?type="+blockType.String(), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return BlocksFromJSON(r.Body), BuildResponse(r)
+}
+
+// GetBlocksForBoardSince returns the blocks of a board updated after
+// since, in milliseconds since the epoch. A zero since fetches all the
+// blocks. The blocks are filtered locally as well, in case the server
+// ignores the modified_since param.
+func (c *Client) GetBlocksForBoardSince(boardID string, since int64) ([]*Block, *Response) {
+	if since == 0 {
+		return c.GetBlocksForBoard(boardID)
+	}
+
+	r, err := c.DoAPIGet(fmt.Sprintf("%s?modified_since=%d", c.GetBlocksRoute(boardID), since), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	blocks := []*Block{}
+	for _, block := range BlocksFromJSON(r.Body) {
+		if block.UpdateAt > since {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, BuildResponse(r)
+}
+
 func (c *Client) GetAllBlocksForBoard(boardID string) ([]*Block, *Response) {
 	r, err := c.DoAPIGet(c.GetAllBlocksRoute(boardID), "")
 	if err != nil {
@@ -259,13 +657,20 @@ func (c *Client) GetAllBlocksForBoard(boardID string) ([]*Block, *Response) {
 }
 
 const disableNotifyQueryParam = "disable_notify=true"
+const excludeContentQueryParam = "include_content=false"
+const permanentQueryParam = "permanent=true"
 
 func (c *Client) PatchBlock(boardID, blockID string, blockPatch *BlockPatch, disableNotify bool) (bool, *Response) {
 	var queryParams string
 	if disableNotify {
 		queryParams = "?" + disableNotifyQueryParam
 	}
-	r, err := c.DoAPIPatch(c.GetBlockRoute(boardID, blockID)+queryParams, toJSON(blockPatch))
+	data, err := patchToJSON(blockPatch, blockPatch.IsValid)
+	if err != nil {
+		return false, &Response{Error: err}
+	}
+
+	r, err := c.DoAPIPatch(c.GetBlockRoute(boardID, blockID)+queryParams, data)
 	if err != nil {
 		return false, BuildErrorResponse(r, err)
 	}
@@ -347,19 +752,19 @@ func (c *Client) DeleteBlock(boardID, blockID string, disableNotify bool) (bool,
 // Cards
 //
 
-func (c *Client) CreateCard(boardID string, card *Card, disableNotify bool) (*Card, *Response) {
+func (c *Client) CreateCard(boardID string, card *Card, disableNotify bool, opts ...RequestOption) (*Card, *Response) {
 	var queryParams string
 	if disableNotify {
 		queryParams = "?" + disableNotifyQueryParam
 	}
-	r, err := c.DoAPIPost(c.GetBoardRoute(boardID)+"/cards"+queryParams, toJSON(card))
+	r, err := c.DoAPIPost(c.GetBoardRoute(boardID)+"/cards"+queryParams, toJSON(card), opts...)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 	defer closeBody(r)
 
 	var cardNew *Card
-	if err := json.NewDecoder(r.Body).Decode(&cardNew); err != nil {
+	if err := decodeJSONBody(r, &cardNew); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
@@ -376,19 +781,82 @@ func (c *Client) GetCards(boardID string, page int, perPage int) ([]*Card, *Resp
 	defer closeBody(r)
 
 	var cards []*Card
-	if err := json.NewDecoder(r.Body).Decode(&cards); err != nil {
+	if err := decodeJSONBody(r, &cards); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
 	return cards, BuildResponse(r)
 }
 
+// GetCardsFiltered returns a page of the cards of a board whose
+// propertyID property matches value. Focalboard servers can't filter
+// cards by property, so the cards are fetched page by page and filtered
+// locally until perPage matching cards are collected or the board has no
+// more cards, and ClientFiltered is always set on the response. Pages
+// refer to the filtered results, e.g. page 1 starts after the first
+// perPage matching cards, so getting a page may fetch every card of the
+// board when only a few of them match.
+func (c *Client) GetCardsFiltered(boardID string, propertyID, value string, page, perPage int) ([]*Card, *Response) {
+	skip := page * perPage
+	filtered := []*Card{}
+	resp := &Response{}
+	for serverPage := 0; len(filtered) < perPage; serverPage++ {
+		var cards []*Card
+		cards, resp = c.GetCards(boardID, serverPage, perPage)
+		if resp.Error != nil {
+			return nil, resp
+		}
+
+		for _, card := range filterCardsByProperty(cards, propertyID, value) {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(filtered) < perPage {
+				filtered = append(filtered, card)
+			}
+		}
+
+		if len(cards) < perPage {
+			break
+		}
+	}
+
+	resp.ClientFiltered = true
+	return filtered, resp
+}
+
+func filterCardsByProperty(cards []*Card, propertyID, value string) []*Card {
+	filtered := []*Card{}
+	for _, card := range cards {
+		switch v := card.Properties[propertyID].(type) {
+		case string:
+			if v == value {
+				filtered = append(filtered, card)
+			}
+		case []any:
+			for _, item := range v {
+				if s, ok := item.(string); ok && s == value {
+					filtered = append(filtered, card)
+					break
+				}
+			}
+		}
+	}
+	return filtered
+}
+
 func (c *Client) PatchCard(cardID string, cardPatch *CardPatch, disableNotify bool) (*Card, *Response) {
+	data, err := patchToJSON(cardPatch, cardPatch.CheckValid)
+	if err != nil {
+		return nil, &Response{Error: err}
+	}
+
 	var queryParams string
 	if disableNotify {
 		queryParams = "?" + disableNotifyQueryParam
 	}
-	r, err := c.DoAPIPatch(c.GetCardRoute(cardID)+queryParams, toJSON(cardPatch))
+	r, err := c.DoAPIPatch(c.GetCardRoute(cardID)+queryParams, data)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -396,7 +864,7 @@ func (c *Client) PatchCard(cardID string, cardPatch *CardPatch, disableNotify bo
 	defer closeBody(r)
 
 	var cardNew *Card
-	if err := json.NewDecoder(r.Body).Decode(&cardNew); err != nil {
+	if err := decodeJSONBody(r, &cardNew); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
@@ -404,7 +872,21 @@ func (c *Client) PatchCard(cardID string, cardPatch *CardPatch, disableNotify bo
 }
 
 func (c *Client) GetCard(cardID string) (*Card, *Response) {
-	r, err := c.DoAPIGet(c.GetCardRoute(cardID), "")
+	return c.getCard(cardID, true)
+}
+
+// GetCardWithoutContent fetches a card asking the server to omit its
+// content blocks, which is useful for list views.
+func (c *Client) GetCardWithoutContent(cardID string) (*Card, *Response) {
+	return c.getCard(cardID, false)
+}
+
+func (c *Client) getCard(cardID string, includeContent bool) (*Card, *Response) {
+	var queryParams string
+	if !includeContent {
+		queryParams = "?" + excludeContentQueryParam
+	}
+	r, err := c.DoAPIGet(c.GetCardRoute(cardID)+queryParams, "")
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -412,13 +894,46 @@ func (c *Client) GetCard(cardID string) (*Card, *Response) {
 	defer closeBody(r)
 
 	var card *Card
-	if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+	if err := decodeJSONBody(r, &card); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
 	return card, BuildResponse(r)
 }
 
+// GetCardHistory returns the revisions of a card, newest first.
+//
+// The card history route, GET /cards/{cardID}/history, is not part of
+// every Boards server: servers that have it answer 501 Not Implemented
+// when history is disabled, and servers without it answer with the
+// router's plain text 404, which carries no JSON error body. Both cases
+// are reported with an ErrNotImplemented error, while a 404 with a JSON
+// error body means that the card doesn't exist.
+func (c *Client) GetCardHistory(cardID string, page, perPage int) ([]*Block, *Response) {
+	url := fmt.Sprintf("%s/history?page=%d&per_page=%d", c.GetCardRoute(cardID), page, perPage)
+	r, err := c.DoAPIGet(url, "")
+	if r != nil && r.StatusCode == http.StatusNotImplemented {
+		return nil, BuildErrorResponse(r, NewErrNotImplemented("card history is disabled"))
+	}
+	if r != nil && r.StatusCode == http.StatusNotFound && errorResponseFromError(err) == nil {
+		return nil, BuildErrorResponse(r, NewErrNotImplemented("card history is not supported by the server"))
+	}
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	var revisions []*Block
+	if err := decodeJSONBody(r, &revisions); err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].UpdateAt > revisions[j].UpdateAt
+	})
+	return revisions, BuildResponse(r)
+}
+
 //
 // Boards and blocks.
 //
@@ -475,6 +990,208 @@ func (c *Client) GetUserCategoryBoards(teamID string) ([]CategoryBoards, *Respon
 	return categoryBoards, BuildResponse(r)
 }
 
+// GetCategory returns a category of the user. As the server doesn't
+// expose single categories, it is looked up in the user's categories. If
+// it doesn't exist the response contains an ErrNotFound error.
+func (c *Client) GetCategory(teamID, categoryID string) (*Category, *Response) {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	for i := range categoryBoards {
+		if categoryBoards[i].ID == categoryID {
+			category := categoryBoards[i].Category
+			return &category, resp
+		}
+	}
+
+	resp.Error = NewErrNotFound("category " + categoryID)
+	return nil, resp
+}
+
+// GetCategoryForBoard returns the category the board is assigned to, or
+// nil if the board isn't in any of the user's categories.
+func (c *Client) GetCategoryForBoard(teamID, boardID string) (*CategoryBoards, *Response) {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	for i := range categoryBoards {
+		for _, metadata := range categoryBoards[i].BoardMetadata {
+			if metadata.BoardID == boardID {
+				return &categoryBoards[i], resp
+			}
+		}
+	}
+	return nil, resp
+}
+
+// SetCategoryBoards makes boardIDs the set of boards of a category. Boards
+// in the set are moved into the category, and the boards of the category
+// that are not in the set are moved back to the default category. If some
+// of the assignments fail, the rest are still attempted and the returned
+// response contains all the errors.
+func (c *Client) SetCategoryBoards(teamID, categoryID string, boardIDs []string) *Response {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	var category *CategoryBoards
+	for i := range categoryBoards {
+		if categoryBoards[i].ID == categoryID {
+			category = &categoryBoards[i]
+		}
+	}
+	defaultCategory := findSystemCategory(categoryBoards, DefaultCategoryName)
+	if category == nil {
+		resp.Error = NewErrNotFound("category " + categoryID)
+		return resp
+	}
+
+	wanted := map[string]bool{}
+	for _, boardID := range boardIDs {
+		wanted[boardID] = true
+	}
+	current := map[string]bool{}
+	for _, metadata := range category.BoardMetadata {
+		current[metadata.BoardID] = true
+	}
+
+	var errs []error
+	for _, boardID := range boardIDs {
+		if current[boardID] {
+			continue
+		}
+		if resp := c.UpdateCategoryBoard(teamID, categoryID, boardID); resp.Error != nil {
+			errs = append(errs, fmt.Errorf("cannot add board %s to category: %w", boardID, resp.Error))
+		}
+	}
+
+	for _, metadata := range category.BoardMetadata {
+		if wanted[metadata.BoardID] {
+			continue
+		}
+		if defaultCategory == nil || defaultCategory.ID == categoryID {
+			errs = append(errs, fmt.Errorf("cannot remove board %s from category: %w", metadata.BoardID, NewErrNotFound("default category")))
+			continue
+		}
+		if resp := c.UpdateCategoryBoard(teamID, defaultCategory.ID, metadata.BoardID); resp.Error != nil {
+			errs = append(errs, fmt.Errorf("cannot remove board %s from category: %w", metadata.BoardID, resp.Error))
+		}
+	}
+
+	resp.Error = errors.Join(errs...)
+	return resp
+}
+
+func findSystemCategory(categoryBoards []CategoryBoards, name string) *CategoryBoards {
+	for i := range categoryBoards {
+		if categoryBoards[i].Type == CategoryTypeSystem && categoryBoards[i].Name == name {
+			return &categoryBoards[i]
+		}
+	}
+	return nil
+}
+
+// FavoriteBoard moves a board to the user's favorites category, creating
+// the category if it doesn't exist yet.
+func (c *Client) FavoriteBoard(teamID, boardID string) *Response {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	var favoritesID string
+	if favorites := findSystemCategory(categoryBoards, FavoritesCategoryName); favorites != nil {
+		favoritesID = favorites.ID
+	} else {
+		me, resp := c.GetMe()
+		if resp.Error != nil {
+			return resp
+		}
+		category, resp := c.CreateCategory(Category{
+			Name:   FavoritesCategoryName,
+			UserID: me.ID,
+			TeamID: teamID,
+			Type:   CategoryTypeSystem,
+		})
+		if resp.Error != nil {
+			return resp
+		}
+		favoritesID = category.ID
+	}
+
+	return c.UpdateCategoryBoard(teamID, favoritesID, boardID)
+}
+
+// UnfavoriteBoard moves a board out of the user's favorites category and
+// back to the default category. If the board isn't a favorite nothing is
+// changed and the response contains an ErrNotFound error, so boards the
+// user filed in custom categories are never moved.
+func (c *Client) UnfavoriteBoard(teamID, boardID string) *Response {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	isFavorite := false
+	if favorites := findSystemCategory(categoryBoards, FavoritesCategoryName); favorites != nil {
+		for _, metadata := range favorites.BoardMetadata {
+			if metadata.BoardID == boardID {
+				isFavorite = true
+				break
+			}
+		}
+	}
+	if !isFavorite {
+		resp.Error = NewErrNotFound("favorite board " + boardID)
+		return resp
+	}
+
+	defaultCategory := findSystemCategory(categoryBoards, DefaultCategoryName)
+	if defaultCategory == nil {
+		resp.Error = NewErrNotFound("default category")
+		return resp
+	}
+
+	return c.UpdateCategoryBoard(teamID, defaultCategory.ID, boardID)
+}
+
+// GetFavoriteBoards returns the boards of the user's favorites category,
+// in the category's order.
+func (c *Client) GetFavoriteBoards(teamID string) ([]*Board, *Response) {
+	categoryBoards, resp := c.GetUserCategoryBoards(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	favorites := findSystemCategory(categoryBoards, FavoritesCategoryName)
+	if favorites == nil || len(favorites.BoardMetadata) == 0 {
+		return []*Board{}, resp
+	}
+
+	boards, resp := c.GetBoardsForTeam(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	boardsMap := make(map[string]*Board, len(boards))
+	for _, board := range boards {
+		boardsMap[board.ID] = board
+	}
+
+	favoriteBoards := []*Board{}
+	for _, metadata := range favorites.BoardMetadata {
+		if board, ok := boardsMap[metadata.BoardID]; ok {
+			favoriteBoards = append(favoriteBoards, board)
+		}
+	}
+	return favoriteBoards, resp
+}
+
 func (c *Client) ReorderCategories(teamID string, newOrder []string) ([]string, *Response) {
 	r, err := c.DoAPIPut(c.GetTeamRoute(teamID)+"/categories/reorder", toJSON(newOrder))
 	if err != nil {
@@ -500,7 +1217,12 @@ func (c *Client) ReorderCategoryBoards(teamID, categoryID string, newOrder []str
 }
 
 func (c *Client) PatchBoardsAndBlocks(pbab *PatchBoardsAndBlocks) (*BoardsAndBlocks, *Response) {
-	r, err := c.DoAPIPatch(c.GetBoardsAndBlocksRoute(), toJSON(pbab))
+	data, err := patchToJSON(pbab, pbab.IsValid)
+	if err != nil {
+		return nil, &Response{Error: err}
+	}
+
+	r, err := c.DoAPIPatch(c.GetBoardsAndBlocksRoute(), data)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -509,6 +1231,53 @@ func (c *Client) PatchBoardsAndBlocks(pbab *PatchBoardsAndBlocks) (*BoardsAndBlo
 	return BoardsAndBlocksFromJSON(r.Body), BuildResponse(r)
 }
 
+// PatchBlocksAtomic applies patches to the blocks of a board in a single
+// atomic request. The request carries an empty patch for the board, as
+// the server requires the board of every patched block to be listed. The
+// server checks the board level permission for that patch, so users that
+// can edit the blocks but lack permission on the board itself, like some
+// editors, are rejected with a forbidden error even where PatchBlock
+// would succeed for them.
+//
+// If the server doesn't support patching boards and blocks together,
+// answering 405 Method Not Allowed or 501 Not Implemented, the blocks are
+// patched one by one, and if one of them fails the response error is a
+// PartialBlockPatchErr listing the blocks that were patched. A 404 means
+// that the board or one of the blocks doesn't exist, so it is returned
+// as is.
+func (c *Client) PatchBlocksAtomic(boardID string, ids []string, patches []*BlockPatch) (bool, *Response) {
+	if len(ids) != len(patches) {
+		return false, &Response{Error: ErrBlockIDsAndPatchesMissmatchInBoardsAndBlocks}
+	}
+
+	if len(ids) == 0 {
+		return true, &Response{}
+	}
+
+	pbab := &PatchBoardsAndBlocks{
+		BoardIDs:     []string{boardID},
+		BoardPatches: []*BoardPatch{{}},
+		BlockIDs:     ids,
+		BlockPatches: patches,
+	}
+	_, resp := c.PatchBoardsAndBlocks(pbab)
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+	default:
+		return resp.Error == nil, resp
+	}
+
+	patchedIDs := []string{}
+	for i, id := range ids {
+		if _, resp = c.PatchBlock(boardID, id, patches[i], false); resp.Error != nil {
+			resp.Error = PartialBlockPatchErr{PatchedIDs: patchedIDs, FailedID: id, Err: resp.Error}
+			return false, resp
+		}
+		patchedIDs = append(patchedIDs, id)
+	}
+	return true, resp
+}
+
 func (c *Client) DeleteBoardsAndBlocks(dbab *DeleteBoardsAndBlocks) (bool, *Response) {
 	r, err := c.DoAPIDelete(c.GetBoardsAndBlocksRoute(), toJSON(dbab))
 	if err != nil {
@@ -550,16 +1319,42 @@ func (c *Client) GetRegisterRoute() string {
 	return "/register"
 }
 
+// Register registers a new user. If the server answers with a conflict
+// because the user already exists, or with an unauthorized status because
+// the invite token is invalid, the response error wraps
+// ErrUserAlreadyExists or ErrInvalidInviteToken respectively.
 func (c *Client) Register(request *RegisterRequest) (bool, *Response) {
 	r, err := c.DoAPIPost(c.GetRegisterRoute(), toJSON(&request))
 	if err != nil {
-		return false, BuildErrorResponse(r, err)
+		return false, BuildErrorResponse(r, registerError(r, err))
 	}
 	defer closeBody(r)
 
 	return true, BuildResponse(r)
 }
 
+// registerError classifies a registration error by the error code of the
+// ErrorResponse sent by the server, falling back to the response status
+// when the body has no error code. The returned error wraps both the
+// sentinel error and the original one.
+func registerError(r *http.Response, err error) error {
+	code := 0
+	if r != nil {
+		code = r.StatusCode
+	}
+	if errResp := errorResponseFromError(err); errResp != nil && errResp.ErrorCode != 0 {
+		code = errResp.ErrorCode
+	}
+
+	switch code {
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %w", ErrUserAlreadyExists, err)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %w", ErrInvalidInviteToken, err)
+	}
+	return err
+}
+
 func (c *Client) GetLoginRoute() string {
 	return "/login"
 }
@@ -647,6 +1442,98 @@ func (c *Client) GetUserList(ids []string) ([]User, *Response) {
 	return users, BuildResponse(r)
 }
 
+// SearchTeamUsers returns the users of the team whose username, name or
+// email matches the search term, using the team users route,
+// GET /teams/{teamID}/users?search=term.
+func (c *Client) SearchTeamUsers(teamID, search string) ([]*User, *Response) {
+	r, err := c.DoAPIGet(c.GetTeamRoute(teamID)+"/users?search="+url.QueryEscape(search), "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	var users []*User
+	if err := decodeJSONBody(r, &users); err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return users, BuildResponse(r)
+}
+
+// GetUsersByUsernames looks up the team users with the given usernames,
+// keeping only exact, case-insensitive matches. Usernames that don't
+// belong to any user are left out of the result. The boards API has no
+// batch lookup by username, users can only be searched within a team, so
+// this sends one SearchTeamUsers request per username.
+func (c *Client) GetUsersByUsernames(teamID string, usernames []string) ([]*User, *Response) {
+	users := []*User{}
+	resp := &Response{}
+	for _, username := range usernames {
+		var found []*User
+		found, resp = c.SearchTeamUsers(teamID, username)
+		if resp.Error != nil {
+			return nil, resp
+		}
+
+		for _, user := range found {
+			if strings.EqualFold(user.Username, username) {
+				users = append(users, user)
+				break
+			}
+		}
+	}
+	return users, resp
+}
+
+// ResolveMentions resolves the @username mentions in the text to the IDs
+// of the team users. Usernames that don't belong to any user are mapped
+// to an empty ID. The team is required because the boards API only
+// searches users within a team, and as GetUsersByUsernames is used, one
+// request is sent per distinct mention.
+func (c *Client) ResolveMentions(teamID, text string) (map[string]string, *Response) {
+	usernames := ExtractMentions(text)
+	mentions := make(map[string]string, len(usernames))
+	if len(usernames) == 0 {
+		return mentions, &Response{}
+	}
+
+	users, resp := c.GetUsersByUsernames(teamID, usernames)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	for _, username := range usernames {
+		mentions[username] = ""
+	}
+	for _, user := range users {
+		if _, ok := mentions[strings.ToLower(user.Username)]; ok {
+			mentions[strings.ToLower(user.Username)] = user.ID
+		}
+	}
+	return mentions, resp
+}
+
+// GetUserListStrict fetches a list of users and reports the requested
+// IDs that the server didn't return, e.g. because the users were deleted.
+func (c *Client) GetUserListStrict(ids []string) (map[string]*User, []string, *Response) {
+	users, resp := c.GetUserList(ids)
+	if resp.Error != nil {
+		return nil, nil, resp
+	}
+
+	usersMap := make(map[string]*User, len(users))
+	for i := range users {
+		usersMap[users[i].ID] = &users[i]
+	}
+
+	missing := []string{}
+	for _, id := range ids {
+		if _, ok := usersMap[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return usersMap, missing, resp
+}
+
 func (c *Client) GetUserChangePasswordRoute(id string) string {
 	return fmt.Sprintf("/users/%s/changepassword", id)
 }
@@ -661,8 +1548,28 @@ func (c *Client) UserChangePassword(id string, data *ChangePasswordRequest) (boo
 	return true, BuildResponse(r)
 }
 
-func (c *Client) CreateBoard(board *Board) (*Board, *Response) {
-	r, err := c.DoAPIPost(c.GetBoardsRoute(), toJSON(board))
+// ChangeMyPassword changes the password of the currently logged in user.
+func (c *Client) ChangeMyPassword(oldPassword, newPassword string) (bool, *Response) {
+	if newPassword == "" {
+		return false, &Response{Error: NewErrAuthParam("new password is required")}
+	}
+	if newPassword == oldPassword {
+		return false, &Response{Error: NewErrAuthParam("new password must be different from the old one")}
+	}
+
+	me, resp := c.GetMe()
+	if resp.Error != nil {
+		return false, resp
+	}
+
+	return c.UserChangePassword(me.ID, &ChangePasswordRequest{
+		OldPassword: oldPassword,
+		NewPassword: newPassword,
+	})
+}
+
+func (c *Client) CreateBoard(board *Board, opts ...RequestOption) (*Board, *Response) {
+	r, err := c.DoAPIPost(c.GetBoardsRoute(), toJSON(board), opts...)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -672,7 +1579,12 @@ func (c *Client) CreateBoard(board *Board) (*Board, *Response) {
 }
 
 func (c *Client) PatchBoard(boardID string, patch *BoardPatch) (*Board, *Response) {
-	r, err := c.DoAPIPatch(c.GetBoardRoute(boardID), toJSON(patch))
+	data, err := patchToJSON(patch, patch.IsValid)
+	if err != nil {
+		return nil, &Response{Error: err}
+	}
+
+	r, err := c.DoAPIPatch(c.GetBoardRoute(boardID), data)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -691,6 +1603,39 @@ func (c *Client) DeleteBoard(boardID string) (bool, *Response) {
 	return true, BuildResponse(r)
 }
 
+// PurgeNotVerifiedWarning is added to the response of a successful
+// DeleteBoardPermanent, as the server may have soft deleted the board.
+const PurgeNotVerifiedWarning = "the server doesn't confirm purges, the board may only have been soft deleted"
+
+// DeleteBoardPermanent deletes a board sending the permanent query param,
+// which asks the server to purge the board instead of soft deleting it.
+// A purged board cannot be restored with UndeleteBoard. As nothing should
+// be purged by accident, confirmPurge must be true or the request is not
+// sent and the response contains an ErrPurgeNotConfirmed error.
+//
+// Stock Focalboard servers ignore the permanent query param: they soft
+// delete the board and answer exactly as DeleteBoard would, so a success
+// response doesn't prove that the board was purged. As the client cannot
+// tell both outcomes apart, a successful response always carries a
+// warning that the board may only have been soft deleted and can still be
+// restored. Compliance workflows must check that their server supports
+// purging before relying on this method.
+func (c *Client) DeleteBoardPermanent(boardID string, confirmPurge bool) (bool, *Response) {
+	if !confirmPurge {
+		return false, &Response{Error: ErrPurgeNotConfirmed}
+	}
+
+	r, err := c.DoAPIDelete(c.GetBoardRoute(boardID)+"?"+permanentQueryParam, "")
+	if err != nil {
+		return false, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	resp := BuildResponse(r)
+	resp.Warnings = append(resp.Warnings, PurgeNotVerifiedWarning)
+	return true, resp
+}
+
 func (c *Client) UndeleteBoard(boardID string) (bool, *Response) {
 	r, err := c.DoAPIPost(c.GetBoardRoute(boardID)+"/undelete", "")
 	if err != nil {
@@ -701,13 +1646,13 @@ func (c *Client) UndeleteBoard(boardID string) (bool, *Response) {
 	return true, BuildResponse(r)
 }
 
-func (c *Client) GetBoard(boardID, readToken string) (*Board, *Response) {
+func (c *Client) GetBoard(boardID, readToken string, opts ...RequestOption) (*Board, *Response) {
 	url := c.GetBoardRoute(boardID)
 	if readToken != "" {
 		url += fmt.Sprintf("?read_token=%s", readToken)
 	}
 
-	r, err := c.DoAPIGet(url, "")
+	r, err := c.DoAPIGet(url, "", opts...)
 	if err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
@@ -716,6 +1661,39 @@ func (c *Client) GetBoard(boardID, readToken string) (*Board, *Response) {
 	return BoardFromJSON(r.Body), BuildResponse(r)
 }
 
+const waitForBoardMaxInterval = 30 * time.Second
+
+// WaitForBoard polls GetBoard while it answers not found, until the board
+// is available or ctx is done, doubling the interval between attempts.
+// Any other error is returned right away. This is useful after operations
+// like DuplicateBoard, where the new board may take a moment to become
+// queryable.
+func (c *Client) WaitForBoard(ctx context.Context, boardID string, interval time.Duration) (*Board, *Response) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	for {
+		board, resp := c.GetBoard(boardID, "", WithContext(ctx))
+		if resp.StatusCode != http.StatusNotFound {
+			return board, resp
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &Response{StatusCode: resp.StatusCode, Header: resp.Header, Error: ctx.Err()}
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > waitForBoardMaxInterval {
+			interval = waitForBoardMaxInterval
+		}
+	}
+}
+
 func (c *Client) GetBoardMetadata(boardID, readToken string) (*BoardMetadata, *Response) {
 	url := c.GetBoardMetadataRoute(boardID)
 	if readToken != "" {
@@ -782,6 +1760,9 @@ func (c *Client) AddMemberToBoard(member *BoardMember) (*BoardMember, *Response)
 	return BoardMemberFromJSON(r.Body), BuildResponse(r)
 }
 
+// JoinBoard adds the current user to a board. If the server answers
+// successfully with an empty body, the returned member is nil and the
+// response has no error.
 func (c *Client) JoinBoard(boardID string) (*BoardMember, *Response) {
 	r, err := c.DoAPIPost(c.GetJoinBoardRoute(boardID), "")
 	if err != nil {
@@ -789,9 +1770,16 @@ func (c *Client) JoinBoard(boardID string) (*BoardMember, *Response) {
 	}
 	defer closeBody(r)
 
-	return BoardMemberFromJSON(r.Body), BuildResponse(r)
+	var member *BoardMember
+	if err := decodeOptionalJSONBody(r, &member); err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return member, BuildResponse(r)
 }
 
+// LeaveBoard removes the current user from a board. If the server answers
+// successfully with an empty body, the returned member is nil and the
+// response has no error.
 func (c *Client) LeaveBoard(boardID string) (*BoardMember, *Response) {
 	r, err := c.DoAPIPost(c.GetLeaveBoardRoute(boardID), "")
 	if err != nil {
@@ -799,7 +1787,11 @@ func (c *Client) LeaveBoard(boardID string) (*BoardMember, *Response) {
 	}
 	defer closeBody(r)
 
-	return BoardMemberFromJSON(r.Body), BuildResponse(r)
+	var member *BoardMember
+	if err := decodeOptionalJSONBody(r, &member); err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	return member, BuildResponse(r)
 }
 
 func (c *Client) UpdateBoardMember(member *BoardMember) (*BoardMember, *Response) {
@@ -812,6 +1804,35 @@ func (c *Client) UpdateBoardMember(member *BoardMember) (*BoardMember, *Response
 	return BoardMemberFromJSON(r.Body), BuildResponse(r)
 }
 
+// SetBoardMemberRole changes the role of a board member. The current
+// membership is fetched first so only its scheme flags are changed, and
+// the role is validated by BoardMember.SetRole before anything is sent.
+// There is no dedicated member role type, so the role is a BoardRole,
+// which can't be BoardRoleNone.
+func (c *Client) SetBoardMemberRole(boardID, userID string, role BoardRole) (*BoardMember, *Response) {
+	members, resp := c.GetMembersForBoard(boardID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	var member *BoardMember
+	for _, m := range members {
+		if m.UserID == userID {
+			member = m
+			break
+		}
+	}
+	if member == nil {
+		resp.Error = NewErrNotFound("board member " + userID)
+		return nil, resp
+	}
+
+	if err := member.SetRole(role); err != nil {
+		return nil, &Response{Error: err}
+	}
+	return c.UpdateBoardMember(member)
+}
+
 func (c *Client) DeleteBoardMember(member *BoardMember) (bool, *Response) {
 	r, err := c.DoAPIDelete(c.GetBoardRoute(member.BoardID)+"/members/"+member.UserID, "")
 	if err != nil {
@@ -826,17 +1847,32 @@ func (c *Client) GetTeamUploadFileRoute(teamID, boardID string) string {
 	return fmt.Sprintf("%s/%s/files", c.GetTeamRoute(teamID), boardID)
 }
 
-/*
-func (c *Client) TeamUploadFile(teamID, boardID string, data io.Reader) (*FileUploadResponse, *Response) {
+// TeamUploadFile uploads a file to a board. Files whose content type,
+// derived from fileName, is unsafe are rejected with ErrUnsafeContentType
+// before uploading. If maxSize is greater than zero, files bigger than
+// maxSize bytes are rejected with ErrFileTooLarge.
+func (c *Client) TeamUploadFile(teamID, boardID, fileName string, data io.Reader, maxSize int64) (*FileUploadResponse, *Response) {
+	if IsUnsafeContentType(NewFileInfo(fileName).MimeType) {
+		return nil, &Response{Error: ErrUnsafeContentType}
+	}
+
+	if maxSize > 0 {
+		data = io.LimitReader(data, maxSize+1)
+	}
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile(UploadFormFileKey, "file")
+	part, err := writer.CreateFormFile(UploadFormFileKey, fileName)
 	if err != nil {
 		return nil, &Response{Error: err}
 	}
-	if _, err = io.Copy(part, data); err != nil {
+	written, err := io.Copy(part, data)
+	if err != nil {
 		return nil, &Response{Error: err}
 	}
+	if maxSize > 0 && written > maxSize {
+		return nil, &Response{Error: ErrFileTooLarge}
+	}
 	writer.Close()
 
 	opt := func(r *http.Request) {
@@ -856,7 +1892,6 @@ func (c *Client) TeamUploadFile(teamID, boardID string, data io.Reader) (*FileUp
 
 	return fileUploadResponse, BuildResponse(r)
 }
-*/
 
 /*
 
@@ -893,6 +1928,28 @@ func (c *Client) CreateSubscription(sub *Subscription) (*Subscription, *Response
 	return subNew, BuildResponse(r)
 }
 
+// UpsertSubscription creates a subscription, or returns the existing one
+// if the server reports a conflict because it already exists. In that
+// case the response keeps the conflict status code but has no error.
+func (c *Client) UpsertSubscription(sub *Subscription) (*Subscription, *Response) {
+	subNew, resp := c.CreateSubscription(sub)
+	if resp.StatusCode != http.StatusConflict {
+		return subNew, resp
+	}
+
+	subs, subsResp := c.GetSubscriptions(sub.SubscriberID)
+	if subsResp.Error != nil {
+		return nil, subsResp
+	}
+
+	for _, existing := range subs {
+		if existing.BlockID == sub.BlockID {
+			return existing, &Response{StatusCode: resp.StatusCode, Header: resp.Header}
+		}
+	}
+	return nil, resp
+}
+
 func (c *Client) DeleteSubscription(blockID string, subscriberID string) *Response {
 	url := fmt.Sprintf("%s/%s/%s", c.GetSubscriptionsRoute(), blockID, subscriberID)
 
@@ -933,6 +1990,16 @@ func (c *Client) GetTemplatesForTeam(teamID string) ([]*Board, *Response) {
 	return BoardsFromJSON(r.Body), BuildResponse(r)
 }
 
+func (c *Client) GetTemplatesSummary(teamID string) ([]*BoardSummary, *Response) {
+	r, err := c.DoAPIGet(c.GetTeamRoute(teamID)+"/templates", "")
+	if err != nil {
+		return nil, BuildErrorResponse(r, err)
+	}
+	defer closeBody(r)
+
+	return BoardSummariesFromJSON(r.Body), BuildResponse(r)
+}
+
 func (c *Client) ExportBoardArchive(boardID string) ([]byte, *Response) {
 	r, err := c.DoAPIGet(c.GetBoardRoute(boardID)+"/archive/export", "")
 	if err != nil {
@@ -947,7 +2014,118 @@ func (c *Client) ExportBoardArchive(boardID string) ([]byte, *Response) {
 	return buf, BuildResponse(r)
 }
 
-/*
+// ExportBoardCSV writes the cards of a board as CSV. If viewID is not
+// empty, only the properties visible in that view are exported.
+func (c *Client) ExportBoardCSV(boardID, viewID string, w io.Writer) *Response {
+	board, resp := c.GetBoard(boardID, "")
+	if resp.Error != nil {
+		return resp
+	}
+
+	var propertyIDs []string
+	if viewID != "" {
+		view, viewResp := c.getBlockForBoard(boardID, viewID)
+		if viewResp.Error != nil {
+			return viewResp
+		}
+		visiblePropertyIDs, err := viewVisiblePropertyIDs(view)
+		if err != nil {
+			return &Response{Error: err}
+		}
+		propertyIDs = visiblePropertyIDs
+	}
+
+	cards, resp := c.getAllCards(boardID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	if err := WriteBoardCSV(w, board, cards, propertyIDs, newUserResolver(c)); err != nil {
+		return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Error: err}
+	}
+	return resp
+}
+
+// ExportBoardMarkdown writes the cards of a board and their content as
+// Markdown.
+func (c *Client) ExportBoardMarkdown(boardID string, w io.Writer) *Response {
+	board, resp := c.GetBoard(boardID, "")
+	if resp.Error != nil {
+		return resp
+	}
+
+	cards, resp := c.getAllCards(boardID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	blocks, resp := c.GetBlocksForBoard(boardID)
+	if resp.Error != nil {
+		return resp
+	}
+
+	if err := WriteBoardMarkdown(w, board, cards, blocks, newUserResolver(c)); err != nil {
+		return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Error: err}
+	}
+	return resp
+}
+
+const exportCardsPerPage = 100
+
+func (c *Client) getAllCards(boardID string) ([]*Card, *Response) {
+	allCards := []*Card{}
+	for page := 0; ; page++ {
+		cards, resp := c.GetCards(boardID, page, exportCardsPerPage)
+		if resp.Error != nil {
+			return nil, resp
+		}
+		allCards = append(allCards, cards...)
+		if len(cards) < exportCardsPerPage {
+			return allCards, resp
+		}
+	}
+}
+
+func (c *Client) getBlockForBoard(boardID, blockID string) (*Block, *Response) {
+	blocks, resp := c.GetBlocksForBoard(boardID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+	for _, block := range blocks {
+		if block.ID == blockID {
+			return block, resp
+		}
+	}
+	resp.Error = NewErrNotFound("block " + blockID)
+	return nil, resp
+}
+
+// userResolver resolves user IDs through the client, caching the results.
+type userResolver struct {
+	client *Client
+	users  map[string]*User
+}
+
+func newUserResolver(c *Client) *userResolver {
+	return &userResolver{
+		client: c,
+		users:  map[string]*User{},
+	}
+}
+
+func (ur *userResolver) GetUserByID(userID string) (*User, error) {
+	if user, ok := ur.users[userID]; ok {
+		return user, nil
+	}
+
+	user, resp := ur.client.GetUser(userID)
+	if resp.Error != nil && resp.StatusCode != http.StatusNotFound {
+		return nil, resp.Error
+	}
+	ur.users[userID] = user
+	return user, nil
+}
+
 func (c *Client) ImportArchive(teamID string, data io.Reader) *Response {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -973,6 +2151,80 @@ func (c *Client) ImportArchive(teamID string, data io.Reader) *Response {
 	return BuildResponse(r)
 }
 
+// SnapshotBoard exports a board as an archive that can be restored later
+// with RestoreBoardSnapshot.
+func (c *Client) SnapshotBoard(boardID string) ([]byte, *Response) {
+	return c.ExportBoardArchive(boardID)
+}
+
+// RestoreBoardSnapshot imports a snapshot taken with SnapshotBoard into a
+// team and returns the restored board. The server assigns new IDs on
+// import, so the restored board and its blocks don't keep the IDs they
+// had when the snapshot was taken. If the snapshot contains several
+// boards, the first one is returned, ImportArchiveBoards returns all of
+// them.
+func (c *Client) RestoreBoardSnapshot(teamID string, snapshot []byte) (*Board, *Response) {
+	boards, resp := c.ImportArchiveBoards(teamID, snapshot)
+	if resp.Error != nil {
+		return nil, resp
+	}
+	return boards[0], resp
+}
+
+// ImportArchiveBoards imports an archive into a team and returns the
+// boards created by the import, in archive order. As the server assigns
+// new IDs on import, the boards are identified by their title among the
+// boards of the team that didn't exist before the import, which excludes
+// the boards created at the same time with a different title. A board
+// created at the same time with the same title as an archived board can
+// still be mistaken for it.
+func (c *Client) ImportArchiveBoards(teamID string, archive []byte) ([]*Board, *Response) {
+	archived, err := BoardsFromArchive(archive)
+	if err != nil {
+		return nil, &Response{Error: err}
+	}
+	if len(archived) == 0 {
+		return nil, &Response{Error: NewErrNotFound("archive board")}
+	}
+
+	boards, resp := c.GetBoardsForTeam(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+	existing := make(map[string]bool, len(boards))
+	for _, board := range boards {
+		existing[board.ID] = true
+	}
+
+	if resp := c.ImportArchive(teamID, bytes.NewReader(archive)); resp.Error != nil {
+		return nil, resp
+	}
+
+	boards, resp = c.GetBoardsForTeam(teamID)
+	if resp.Error != nil {
+		return nil, resp
+	}
+
+	restored := make([]*Board, 0, len(archived))
+	for _, archivedBoard := range archived {
+		var match *Board
+		for _, board := range boards {
+			if !existing[board.ID] && board.Title == archivedBoard.Title {
+				match = board
+				break
+			}
+		}
+		if match == nil {
+			resp.Error = NewErrNotFound("restored board " + archivedBoard.Title)
+			return nil, resp
+		}
+		existing[match.ID] = true
+		restored = append(restored, match)
+	}
+	return restored, resp
+}
+
+/*
 func (c *Client) GetLimits() (*BoardsCloudLimits, *Response) {
 	r, err := c.DoAPIGet("/limits", "")
 	if err != nil {
@@ -1008,14 +2260,26 @@ func (c *Client) GetStatistics() (*BoardsStatistics, *Response) {
 	defer closeBody(r)
 
 	var stats *BoardsStatistics
-	err = json.NewDecoder(r.Body).Decode(&stats)
-	if err != nil {
+	if err := decodeJSONBody(r, &stats); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
 	return stats, BuildResponse(r)
 }
 
+// ComplianceMaxPerPage is the maximum page size the server allows on the
+// compliance endpoints. Bigger page sizes are capped by the server.
+const ComplianceMaxPerPage = 1000
+
+func buildComplianceResponse(r *http.Response, perPage int) *Response {
+	resp := BuildResponse(r)
+	if perPage > ComplianceMaxPerPage {
+		resp.Warnings = append(resp.Warnings,
+			fmt.Sprintf("per_page %d exceeds the server maximum of %d, results are capped", perPage, ComplianceMaxPerPage))
+	}
+	return resp
+}
+
 func (c *Client) GetBoardsForCompliance(teamID string, page, perPage int) (*BoardsComplianceResponse, *Response) {
 	query := fmt.Sprintf("?team_id=%s&page=%d&per_page=%d", teamID, page, perPage)
 	r, err := c.DoAPIGet("/admin/boards"+query, "")
@@ -1025,12 +2289,11 @@ func (c *Client) GetBoardsForCompliance(teamID string, page, perPage int) (*Boar
 	defer closeBody(r)
 
 	var res *BoardsComplianceResponse
-	err = json.NewDecoder(r.Body).Decode(&res)
-	if err != nil {
+	if err := decodeJSONBody(r, &res); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
-	return res, BuildResponse(r)
+	return res, buildComplianceResponse(r, perPage)
 }
 
 func (c *Client) GetBoardsComplianceHistory(
@@ -1044,12 +2307,11 @@ func (c *Client) GetBoardsComplianceHistory(
 	defer closeBody(r)
 
 	var res *BoardsComplianceHistoryResponse
-	err = json.NewDecoder(r.Body).Decode(&res)
-	if err != nil {
+	if err := decodeJSONBody(r, &res); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
-	return res, BuildResponse(r)
+	return res, buildComplianceResponse(r, perPage)
 }
 
 func (c *Client) GetBlocksComplianceHistory(
@@ -1063,12 +2325,11 @@ func (c *Client) GetBlocksComplianceHistory(
 	defer closeBody(r)
 
 	var res *BlocksComplianceHistoryResponse
-	err = json.NewDecoder(r.Body).Decode(&res)
-	if err != nil {
+	if err := decodeJSONBody(r, &res); err != nil {
 		return nil, BuildErrorResponse(r, err)
 	}
 
-	return res, BuildResponse(r)
+	return res, buildComplianceResponse(r, perPage)
 }
 
 func (c *Client) HideBoard(teamID, categoryID, boardID string) *Response {
@@ -0,0 +1,2199 @@
+package boards
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient starts a test server with the given handler and returns a
+// client pointing to it.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...ClientOption) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL, "test-token", opts...)
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, status int, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("failed to encode response: %s", err)
+	}
+}
+
+func writeError(t *testing.T, w http.ResponseWriter, status int, message string) {
+	t.Helper()
+
+	writeJSON(t, w, status, ErrorResponse{Error: message, ErrorCode: status})
+}
+
+func TestGetCard(t *testing.T) {
+	var query string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v2/cards/card1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		query = r.URL.RawQuery
+		writeJSON(t, w, http.StatusOK, Card{ID: "card1", Title: "Card"})
+	})
+
+	t.Run("includes content by default", func(t *testing.T) {
+		card, resp := client.GetCard("card1")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if card.ID != "card1" {
+			t.Errorf("expected card card1, got %q", card.ID)
+		}
+		if query != "" {
+			t.Errorf("expected no query params, got %q", query)
+		}
+	})
+
+	t.Run("without content", func(t *testing.T) {
+		card, resp := client.GetCardWithoutContent("card1")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if card.ID != "card1" {
+			t.Errorf("expected card card1, got %q", card.ID)
+		}
+		if query != excludeContentQueryParam {
+			t.Errorf("expected query %q, got %q", excludeContentQueryParam, query)
+		}
+	})
+}
+
+func TestChangeMyPassword(t *testing.T) {
+	t.Run("rejects an empty password without sending", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		success, resp := client.ChangeMyPassword("old", "")
+		if success {
+			t.Error("expected the change to fail")
+		}
+		var errAuth *ErrAuthParam
+		if !errors.As(resp.Error, &errAuth) {
+			t.Errorf("expected an ErrAuthParam, got %v", resp.Error)
+		}
+	})
+
+	t.Run("rejects the same password without sending", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		success, resp := client.ChangeMyPassword("secret", "secret")
+		if success {
+			t.Error("expected the change to fail")
+		}
+		var errAuth *ErrAuthParam
+		if !errors.As(resp.Error, &errAuth) {
+			t.Errorf("expected an ErrAuthParam, got %v", resp.Error)
+		}
+	})
+
+	t.Run("changes the password of the current user", func(t *testing.T) {
+		var data ChangePasswordRequest
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /api/v2/users/me":
+				writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+			case "POST /api/v2/users/user1/changepassword":
+				_ = json.NewDecoder(r.Body).Decode(&data)
+				writeJSON(t, w, http.StatusOK, struct{}{})
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+
+		success, resp := client.ChangeMyPassword("old", "new")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Error("expected the change to succeed")
+		}
+		if data.OldPassword != "old" || data.NewPassword != "new" {
+			t.Errorf("unexpected passwords sent: %+v", data)
+		}
+	})
+}
+
+func TestDeleteBoardPermanent(t *testing.T) {
+	t.Run("requires confirmation", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		success, resp := client.DeleteBoardPermanent("board1", false)
+		if success {
+			t.Error("expected the deletion to fail")
+		}
+		if !errors.Is(resp.Error, ErrPurgeNotConfirmed) {
+			t.Errorf("expected ErrPurgeNotConfirmed, got %v", resp.Error)
+		}
+	})
+
+	t.Run("purged boards can't be undeleted", func(t *testing.T) {
+		purged := map[string]bool{}
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "DELETE /api/v2/boards/board1":
+				if r.URL.RawQuery != permanentQueryParam {
+					t.Errorf("expected query %q, got %q", permanentQueryParam, r.URL.RawQuery)
+				}
+				purged["board1"] = true
+				writeJSON(t, w, http.StatusOK, struct{}{})
+			case "POST /api/v2/boards/board1/undelete":
+				if purged["board1"] {
+					writeError(t, w, http.StatusNotFound, "board not found")
+					return
+				}
+				writeJSON(t, w, http.StatusOK, struct{}{})
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+
+		success, resp := client.DeleteBoardPermanent("board1", true)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Fatal("expected the deletion to succeed")
+		}
+		if !reflect.DeepEqual(resp.Warnings, []string{PurgeNotVerifiedWarning}) {
+			t.Errorf("expected the purge not verified warning, got %v", resp.Warnings)
+		}
+
+		success, resp = client.UndeleteBoard("board1")
+		if success {
+			t.Error("expected the undelete to fail")
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+		if errResp := errorResponseFromError(resp.Error); errResp == nil || errResp.ErrorCode != http.StatusNotFound {
+			t.Errorf("expected a not found error, got %v", resp.Error)
+		}
+	})
+}
+
+func TestGetBlocksForBoardByType(t *testing.T) {
+	t.Run("sends only the type query param", func(t *testing.T) {
+		var query url.Values
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v2/boards/board1/blocks" {
+				t.Errorf("unexpected path %q", r.URL.Path)
+			}
+			query = r.URL.Query()
+			writeJSON(t, w, http.StatusOK, []*Block{{ID: "card1", Type: TypeCard}})
+		})
+
+		blocks, resp := client.GetBlocksForBoardByType("board1", TypeCard)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if len(blocks) != 1 || blocks[0].ID != "card1" {
+			t.Errorf("unexpected blocks %+v", blocks)
+		}
+		expected := url.Values{"type": []string{"card"}}
+		if !reflect.DeepEqual(query, expected) {
+			t.Errorf("expected query %v, got %v", expected, query)
+		}
+	})
+
+	t.Run("rejects invalid types without sending", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		blocks, resp := client.GetBlocksForBoardByType("board1", "unknown")
+		if blocks != nil {
+			t.Errorf("expected no blocks, got %+v", blocks)
+		}
+		if resp.Error == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestNewClientAPIURL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		opts     []ClientOption
+		expected string
+	}{
+		{
+			name:     "plain",
+			url:      "https://host",
+			expected: "https://host/api/v2",
+		},
+		{
+			name:     "trailing slash",
+			url:      "https://host/",
+			expected: "https://host/api/v2",
+		},
+		{
+			name:     "subpath",
+			url:      "https://host/boards",
+			expected: "https://host/boards/api/v2",
+		},
+		{
+			name:     "plugin mounted",
+			url:      "https://host",
+			opts:     []ClientOption{WithBasePath(PluginBasePath)},
+			expected: "https://host/plugins/focalboard/api/v2",
+		},
+		{
+			name:     "plugin mounted under a subpath",
+			url:      "https://host/chat",
+			opts:     []ClientOption{WithBasePath(PluginBasePath)},
+			expected: "https://host/chat/plugins/focalboard/api/v2",
+		},
+		{
+			name:     "plugin prefix already in the URL",
+			url:      "https://host/plugins/focalboard/",
+			opts:     []ClientOption{WithBasePath(PluginBasePath)},
+			expected: "https://host/plugins/focalboard/api/v2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := NewClient(tc.url, "", tc.opts...)
+			if client.APIURL != tc.expected {
+				t.Errorf("expected API URL %q, got %q", tc.expected, client.APIURL)
+			}
+		})
+	}
+
+	t.Run("requests use the base path", func(t *testing.T) {
+		var path string
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+		}, WithBasePath(PluginBasePath))
+
+		if _, resp := client.GetMe(); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if path != "/plugins/focalboard/api/v2/users/me" {
+			t.Errorf("unexpected path %q", path)
+		}
+	})
+}
+
+func TestBuildResponse(t *testing.T) {
+	t.Run("nil response", func(t *testing.T) {
+		resp := BuildResponse(nil)
+		if resp == nil {
+			t.Fatal("expected a response")
+		}
+		if resp.StatusCode != 0 || resp.Error != nil || resp.Header != nil {
+			t.Errorf("expected a zero value response, got %+v", resp)
+		}
+	})
+
+	t.Run("copies status and headers", func(t *testing.T) {
+		header := http.Header{"Etag": []string{"abc"}}
+		resp := BuildResponse(&http.Response{StatusCode: http.StatusNoContent, Header: header})
+		if resp.StatusCode != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d", http.StatusNoContent, resp.StatusCode)
+		}
+		if resp.Header.Get("Etag") != "abc" {
+			t.Errorf("expected the response headers, got %v", resp.Header)
+		}
+	})
+}
+
+func newTestCategoryBoards() []CategoryBoards {
+	return []CategoryBoards{
+		{
+			Category:      Category{ID: "category1", Name: DefaultCategoryName, TeamID: "team1", Type: CategoryTypeSystem},
+			BoardMetadata: []CategoryBoardMetadata{{BoardID: "board1"}},
+		},
+		{
+			Category:      Category{ID: "category2", Name: "Projects", TeamID: "team1", Type: CategoryTypeCustom},
+			BoardMetadata: []CategoryBoardMetadata{{BoardID: "board2"}, {BoardID: "board3"}},
+		},
+	}
+}
+
+func TestGetCategoryForBoard(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v2/teams/team1/categories" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, http.StatusOK, newTestCategoryBoards())
+	})
+
+	t.Run("categorized board", func(t *testing.T) {
+		category, resp := client.GetCategoryForBoard("team1", "board3")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if category == nil || category.ID != "category2" {
+			t.Errorf("expected category category2, got %+v", category)
+		}
+	})
+
+	t.Run("uncategorized board", func(t *testing.T) {
+		category, resp := client.GetCategoryForBoard("team1", "board4")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if category != nil {
+			t.Errorf("expected no category, got %+v", category)
+		}
+	})
+}
+
+func TestWithIdempotencyKey(t *testing.T) {
+	t.Run("sends the same key and body on each retry", func(t *testing.T) {
+		var keys, bodies []string
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			bodies = append(bodies, string(body))
+			if len(keys) < DefaultRetryPolicy.MaxAttempts {
+				writeError(t, w, http.StatusServiceUnavailable, "unavailable")
+				return
+			}
+			writeJSON(t, w, http.StatusOK, Card{ID: "card1"})
+		})
+
+		card, resp := client.CreateCard("board1", &Card{Title: "Card"}, false, WithIdempotencyKey("key1"))
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if card.ID != "card1" {
+			t.Errorf("expected card card1, got %q", card.ID)
+		}
+		if len(keys) != DefaultRetryPolicy.MaxAttempts {
+			t.Fatalf("expected %d attempts, got %d", DefaultRetryPolicy.MaxAttempts, len(keys))
+		}
+		for i := range keys {
+			if keys[i] != "key1" {
+				t.Errorf("attempt %d sent key %q", i, keys[i])
+			}
+			if bodies[i] != bodies[0] || bodies[i] == "" {
+				t.Errorf("attempt %d sent body %q, expected %q", i, bodies[i], bodies[0])
+			}
+		}
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		attempts := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			writeError(t, w, http.StatusServiceUnavailable, "unavailable")
+		})
+
+		_, resp := client.CreateBoard(&Board{TeamID: "team1"}, WithIdempotencyKey("key1"))
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		if attempts != DefaultRetryPolicy.MaxAttempts {
+			t.Errorf("expected %d attempts, got %d", DefaultRetryPolicy.MaxAttempts, attempts)
+		}
+	})
+
+	t.Run("doesn't retry client errors", func(t *testing.T) {
+		attempts := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			writeError(t, w, http.StatusBadRequest, "invalid card")
+		})
+
+		_, resp := client.CreateCard("board1", &Card{}, false, WithIdempotencyKey("key1"))
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("requests without a key are not retried", func(t *testing.T) {
+		attempts := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			writeError(t, w, http.StatusServiceUnavailable, "unavailable")
+		})
+
+		_, resp := client.CreateCard("board1", &Card{}, false)
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+}
+
+func TestGetCardsFiltered(t *testing.T) {
+	cards := []*Card{
+		{ID: "card1", Properties: map[string]any{"status": "done"}},
+		{ID: "card2", Properties: map[string]any{"status": "todo"}},
+		{ID: "card3", Properties: map[string]any{"status": []any{"todo", "done"}}},
+		{ID: "card4", Properties: map[string]any{"status": "todo"}},
+		{ID: "card5", Properties: map[string]any{"status": "done"}},
+	}
+
+	// newCardsClient pages over cards the way the server does and records
+	// the requested pages.
+	newCardsClient := func(t *testing.T, pages *[]int) *Client {
+		return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v2/boards/board1/cards" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			if query := r.URL.Query(); len(query) != 2 {
+				t.Errorf("expected only the pagination params, got %v", query)
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+			*pages = append(*pages, page)
+
+			pageCards := []*Card{}
+			for i := page * perPage; i < len(cards) && i < (page+1)*perPage; i++ {
+				pageCards = append(pageCards, cards[i])
+			}
+			writeJSON(t, w, http.StatusOK, pageCards)
+		})
+	}
+
+	testCases := []struct {
+		name          string
+		value         string
+		page          int
+		perPage       int
+		expectedIDs   []string
+		expectedPages []int
+	}{
+		{name: "fills the page across server pages", value: "done", page: 0, perPage: 2, expectedIDs: []string{"card1", "card3"}, expectedPages: []int{0, 1}},
+		{name: "skips previous filtered pages", value: "done", page: 1, perPage: 2, expectedIDs: []string{"card5"}, expectedPages: []int{0, 1, 2}},
+		{name: "stops at the last server page", value: "todo", page: 0, perPage: 10, expectedIDs: []string{"card2", "card3", "card4"}, expectedPages: []int{0}},
+		{name: "no matches", value: "unknown", page: 0, perPage: 2, expectedIDs: []string{}, expectedPages: []int{0, 1, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pages := []int{}
+			client := newCardsClient(t, &pages)
+
+			filtered, resp := client.GetCardsFiltered("board1", "status", tc.value, tc.page, tc.perPage)
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if !resp.ClientFiltered {
+				t.Error("expected the client filter to be reported")
+			}
+			ids := []string{}
+			for _, card := range filtered {
+				ids = append(ids, card.ID)
+			}
+			if !reflect.DeepEqual(ids, tc.expectedIDs) {
+				t.Errorf("expected cards %v, got %v", tc.expectedIDs, ids)
+			}
+			if !reflect.DeepEqual(pages, tc.expectedPages) {
+				t.Errorf("expected pages %v to be fetched, got %v", tc.expectedPages, pages)
+			}
+		})
+	}
+
+	t.Run("server error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusForbidden, "forbidden")
+		})
+
+		filtered, resp := client.GetCardsFiltered("board1", "status", "done", 0, 2)
+		if filtered != nil {
+			t.Errorf("expected no cards, got %+v", filtered)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+}
+
+func writeGzipJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(v); err != nil {
+		t.Errorf("failed to encode response: %s", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Errorf("failed to compress response: %s", err)
+	}
+}
+
+func TestGetBoardsComplianceHistory(t *testing.T) {
+	history := BoardsComplianceHistoryResponse{
+		HasNext: true,
+		Results: []*BoardHistory{{ID: "board1", TeamID: "team1", IsDeleted: true}},
+	}
+
+	testCases := []struct {
+		name      string
+		transport *http.Transport
+	}{
+		{
+			name:      "decompressed by the client",
+			transport: &http.Transport{DisableCompression: true},
+		},
+		{
+			name:      "decompressed by the transport",
+			transport: &http.Transport{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var query url.Values
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/v2/admin/boards_history" {
+					t.Errorf("unexpected path %q", r.URL.Path)
+				}
+				query = r.URL.Query()
+				writeGzipJSON(t, w, history)
+			})
+			client.HTTPClient = &http.Client{Transport: tc.transport}
+
+			res, resp := client.GetBoardsComplianceHistory(100, true, "team1", 0, 50)
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if !reflect.DeepEqual(res, &history) {
+				t.Errorf("expected %+v, got %+v", history, res)
+			}
+			if len(resp.Warnings) != 0 {
+				t.Errorf("expected no warnings, got %v", resp.Warnings)
+			}
+			if query.Get("modified_since") != "100" || query.Get("include_deleted") != "true" {
+				t.Errorf("unexpected query %v", query)
+			}
+		})
+	}
+
+	t.Run("warns about capped page sizes", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, http.StatusOK, history)
+		})
+
+		_, resp := client.GetBoardsComplianceHistory(0, false, "team1", 0, ComplianceMaxPerPage+1)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if len(resp.Warnings) != 1 {
+			t.Errorf("expected a warning, got %v", resp.Warnings)
+		}
+	})
+
+	t.Run("invalid gzip body", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write([]byte("not gzip"))
+		})
+		client.HTTPClient = &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+		res, resp := client.GetBoardsComplianceHistory(0, false, "team1", 0, 50)
+		if res != nil {
+			t.Errorf("expected no result, got %+v", res)
+		}
+		var decodeErr *DecodeError
+		if !errors.As(resp.Error, &decodeErr) {
+			t.Errorf("expected a DecodeError, got %v", resp.Error)
+		}
+	})
+}
+
+func TestWaitForBoard(t *testing.T) {
+	t.Run("polls until the board is available", func(t *testing.T) {
+		attempts := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts <= 2 {
+				writeError(t, w, http.StatusNotFound, "board not found")
+				return
+			}
+			writeJSON(t, w, http.StatusOK, Board{ID: "board1"})
+		})
+
+		board, resp := client.WaitForBoard(context.Background(), "board1", time.Millisecond)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if board == nil || board.ID != "board1" {
+			t.Errorf("expected board board1, got %+v", board)
+		}
+		if attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts)
+		}
+	})
+
+	t.Run("returns other errors right away", func(t *testing.T) {
+		attempts := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			writeError(t, w, http.StatusUnauthorized, "unauthorized")
+		})
+
+		board, resp := client.WaitForBoard(context.Background(), "board1", time.Millisecond)
+		if board != nil {
+			t.Errorf("expected no board, got %+v", board)
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+		if attempts != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts)
+		}
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusNotFound, "board not found")
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		board, resp := client.WaitForBoard(ctx, "board1", time.Millisecond)
+		if board != nil {
+			t.Errorf("expected no board, got %+v", board)
+		}
+		if !errors.Is(resp.Error, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline exceeded error, got %v", resp.Error)
+		}
+	})
+}
+
+// newTestCategoryServer serves the categories of team1 from categoryBoards,
+// moving boards between categories when they are updated. Assignments to
+// the boards in failing are rejected.
+func newTestCategoryServer(t *testing.T, categoryBoards []CategoryBoards, failing ...string) *Client {
+	t.Helper()
+
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/api/v2/teams/team1/categories" {
+			writeJSON(t, w, http.StatusOK, categoryBoards)
+			return
+		}
+
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v2/teams/team1/categories/"), "/")
+		if r.Method != http.MethodPost || len(parts) != 3 || parts[1] != "boards" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			return
+		}
+		categoryID, boardID := parts[0], parts[2]
+		for _, id := range failing {
+			if id == boardID {
+				writeError(t, w, http.StatusForbidden, "forbidden")
+				return
+			}
+		}
+
+		moveCategoryBoard(categoryBoards, categoryID, boardID)
+		writeJSON(t, w, http.StatusOK, struct{}{})
+	})
+}
+
+// moveCategoryBoard moves a board to a category, removing it from the
+// category it was in, as the server does.
+func moveCategoryBoard(categoryBoards []CategoryBoards, categoryID, boardID string) {
+	for i := range categoryBoards {
+		metadata := []CategoryBoardMetadata{}
+		for _, m := range categoryBoards[i].BoardMetadata {
+			if m.BoardID != boardID {
+				metadata = append(metadata, m)
+			}
+		}
+		if categoryBoards[i].ID == categoryID {
+			metadata = append(metadata, CategoryBoardMetadata{BoardID: boardID})
+		}
+		categoryBoards[i].BoardMetadata = metadata
+	}
+}
+
+func categoryBoardIDs(categoryBoards []CategoryBoards, categoryID string) []string {
+	ids := []string{}
+	for _, category := range categoryBoards {
+		if category.ID != categoryID {
+			continue
+		}
+		for _, metadata := range category.BoardMetadata {
+			ids = append(ids, metadata.BoardID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSetCategoryBoards(t *testing.T) {
+	t.Run("reconciles the set of boards", func(t *testing.T) {
+		categoryBoards := newTestCategoryBoards()
+		client := newTestCategoryServer(t, categoryBoards)
+
+		if resp := client.SetCategoryBoards("team1", "category2", []string{"board1", "board3"}); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+
+		if ids := categoryBoardIDs(categoryBoards, "category2"); !reflect.DeepEqual(ids, []string{"board1", "board3"}) {
+			t.Errorf("unexpected boards in category2: %v", ids)
+		}
+		if ids := categoryBoardIDs(categoryBoards, "category1"); !reflect.DeepEqual(ids, []string{"board2"}) {
+			t.Errorf("expected board2 to be moved to the default category, got %v", ids)
+		}
+	})
+
+	t.Run("aggregates partial failures", func(t *testing.T) {
+		categoryBoards := newTestCategoryBoards()
+		client := newTestCategoryServer(t, categoryBoards, "board1", "board2")
+
+		resp := client.SetCategoryBoards("team1", "category2", []string{"board1", "board4"})
+		if resp.Error == nil {
+			t.Fatal("expected an error")
+		}
+		for _, boardID := range []string{"board1", "board2"} {
+			if !strings.Contains(resp.Error.Error(), boardID) {
+				t.Errorf("expected the error to mention %s, got %q", boardID, resp.Error)
+			}
+		}
+		if ids := categoryBoardIDs(categoryBoards, "category2"); !reflect.DeepEqual(ids, []string{"board2", "board4"}) {
+			t.Errorf("expected the other assignments to be applied, got %v", ids)
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		client := newTestCategoryServer(t, newTestCategoryBoards())
+
+		resp := client.SetCategoryBoards("team1", "category3", []string{"board1"})
+		var errNotFound *ErrNotFound
+		if !errors.As(resp.Error, &errNotFound) {
+			t.Errorf("expected an ErrNotFound, got %v", resp.Error)
+		}
+	})
+}
+
+func TestGetUserListStrict(t *testing.T) {
+	known := map[string]User{
+		"user1": {ID: "user1", Username: "alice"},
+		"user2": {ID: "user2", Username: "bob"},
+	}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/users" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var ids []string
+		_ = json.NewDecoder(r.Body).Decode(&ids)
+		users := []User{}
+		for _, id := range ids {
+			if user, ok := known[id]; ok {
+				users = append(users, user)
+			}
+		}
+		writeJSON(t, w, http.StatusOK, users)
+	})
+
+	users, missing, resp := client.GetUserListStrict([]string{"user1", "user3", "user2", "user4"})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if len(users) != 2 || users["user1"].Username != "alice" || users["user2"].Username != "bob" {
+		t.Errorf("unexpected users %+v", users)
+	}
+	if !reflect.DeepEqual(missing, []string{"user3", "user4"}) {
+		t.Errorf("expected user3 and user4 to be missing, got %v", missing)
+	}
+}
+
+func TestCleanURLPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{"clean URL", "https://host/api/v2/boards", "https://host/api/v2/boards"},
+		{"duplicate slashes", "https://host//api/v2//boards///board1", "https://host/api/v2/boards/board1"},
+		{"query is untouched", "https://host/api//v2?redirect=https://other//path", "https://host/api/v2?redirect=https://other//path"},
+		{"fragment is untouched", "https://host//a#b//c", "https://host/a#b//c"},
+		{"no scheme", "/api//v2", "/api/v2"},
+		{"scheme separator in the path", "/api//v2/x://y", "/api/v2/x:/y"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if cleaned := cleanURLPath(tc.url); cleaned != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, cleaned)
+			}
+		})
+	}
+
+	t.Run("requests are sent to the clean path", func(t *testing.T) {
+		var path string
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			writeJSON(t, w, http.StatusOK, Board{ID: "board1"})
+		})
+		client.APIURL += "/"
+
+		if _, resp := client.GetBoard("board1", ""); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if path != "/api/v2/boards/board1" {
+			t.Errorf("unexpected path %q", path)
+		}
+	})
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []ClientOption
+		expected map[string]string
+	}{
+		{
+			name:     "default",
+			expected: map[string]string{"X-Requested-With": "XMLHttpRequest"},
+		},
+		{
+			name:     "without X-Requested-With",
+			opts:     []ClientOption{WithoutXRequestedWith()},
+			expected: map[string]string{"X-Requested-With": ""},
+		},
+		{
+			name: "custom headers",
+			opts: []ClientOption{WithDefaultHeaders(map[string]string{"X-Custom": "value"})},
+			expected: map[string]string{
+				"X-Requested-With": "",
+				"X-Custom":         "value",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var header http.Header
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				header = r.Header
+				writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+			}, tc.opts...)
+
+			if _, resp := client.GetMe(); resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			for key, value := range tc.expected {
+				if header.Get(key) != value {
+					t.Errorf("expected header %s to be %q, got %q", key, value, header.Get(key))
+				}
+			}
+		})
+	}
+}
+
+func TestGetBlocksForBoardSince(t *testing.T) {
+	blocks := []*Block{
+		{ID: "block1", UpdateAt: 100},
+		{ID: "block2", UpdateAt: 200},
+		{ID: "block3", UpdateAt: 300},
+	}
+
+	var query url.Values
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/boards/board1/blocks" {
+			t.Errorf("unexpected path %q", r.URL.Path)
+		}
+		query = r.URL.Query()
+
+		since, _ := strconv.ParseInt(query.Get("modified_since"), 10, 64)
+		modified := []*Block{}
+		for _, block := range blocks {
+			if block.UpdateAt > since {
+				modified = append(modified, block)
+			}
+		}
+		writeJSON(t, w, http.StatusOK, modified)
+	})
+
+	t.Run("modified since", func(t *testing.T) {
+		modified, resp := client.GetBlocksForBoardSince("board1", 200)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if query.Get("modified_since") != "200" {
+			t.Errorf("expected modified_since 200, got %q", query.Get("modified_since"))
+		}
+		if len(modified) != 1 || modified[0].ID != "block3" {
+			t.Errorf("expected only block3, got %+v", modified)
+		}
+	})
+
+	t.Run("zero since fetches everything", func(t *testing.T) {
+		all, resp := client.GetBlocksForBoardSince("board1", 0)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if query.Has("modified_since") {
+			t.Errorf("expected no modified_since param, got %v", query)
+		}
+		if len(all) != 3 {
+			t.Errorf("expected all the blocks, got %+v", all)
+		}
+	})
+}
+
+func TestGetBlocksForBoardSinceFiltersLocally(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, []*Block{
+			{ID: "block1", UpdateAt: 100},
+			{ID: "block2", UpdateAt: 300},
+		})
+	})
+
+	modified, resp := client.GetBlocksForBoardSince("board1", 200)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if len(modified) != 1 || modified[0].ID != "block2" {
+		t.Errorf("expected only block2, got %+v", modified)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": `))
+	})
+
+	testCases := []struct {
+		name     string
+		call     func() *Response
+		endpoint string
+		target   string
+	}{
+		{
+			name:     "card",
+			call:     func() *Response { _, resp := client.GetCard("card1"); return resp },
+			endpoint: "GET /api/v2/cards/card1",
+			target:   "*Card",
+		},
+		{
+			name:     "cards",
+			call:     func() *Response { _, resp := client.GetCards("board1", 0, 10); return resp },
+			endpoint: "GET /api/v2/boards/board1/cards",
+			target:   "[]*Card",
+		},
+		{
+			name:     "statistics",
+			call:     func() *Response { _, resp := client.GetStatistics(); return resp },
+			endpoint: "GET /api/v2/statistics",
+			target:   "*BoardsStatistics",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := tc.call()
+
+			var decodeErr *DecodeError
+			if !errors.As(resp.Error, &decodeErr) {
+				t.Fatalf("expected a DecodeError, got %v", resp.Error)
+			}
+			if decodeErr.Target != tc.target {
+				t.Errorf("expected target %q, got %q", tc.target, decodeErr.Target)
+			}
+			if decodeErr.Endpoint != tc.endpoint {
+				t.Errorf("expected endpoint %q, got %q", tc.endpoint, decodeErr.Endpoint)
+			}
+			if string(decodeErr.Body) != `{"id": ` {
+				t.Errorf("expected the raw body to be kept, got %q", decodeErr.Body)
+			}
+			expected := "failed to decode " + tc.target + " from " + tc.endpoint
+			if !strings.HasPrefix(decodeErr.Error(), expected) {
+				t.Errorf("expected error to start with %q, got %q", expected, decodeErr.Error())
+			}
+			var syntaxErr *json.SyntaxError
+			if !errors.As(resp.Error, &syntaxErr) {
+				t.Errorf("expected the JSON error to be wrapped, got %v", decodeErr.Err)
+			}
+		})
+	}
+}
+
+func TestWithDefaultContext(t *testing.T) {
+	t.Run("cancelling aborts in-flight requests", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		received := make(chan struct{})
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			close(received)
+			<-r.Context().Done()
+		}, WithDefaultContext(ctx))
+
+		go func() {
+			<-received
+			cancel()
+		}()
+
+		_, resp := client.GetMe()
+		if !errors.Is(resp.Error, context.Canceled) {
+			t.Errorf("expected a context canceled error, got %v", resp.Error)
+		}
+	})
+
+	t.Run("per-request context wins", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, http.StatusOK, Board{ID: "board1"})
+		}, WithDefaultContext(ctx))
+
+		if _, resp := client.GetBoard("board1", ""); !errors.Is(resp.Error, context.Canceled) {
+			t.Errorf("expected a context canceled error, got %v", resp.Error)
+		}
+
+		board, resp := client.GetBoard("board1", "", WithContext(context.Background()))
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if board == nil || board.ID != "board1" {
+			t.Errorf("expected board board1, got %+v", board)
+		}
+	})
+}
+
+func TestGetCategory(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, http.StatusOK, newTestCategoryBoards())
+	})
+
+	t.Run("found", func(t *testing.T) {
+		category, resp := client.GetCategory("team1", "category2")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if category == nil || category.ID != "category2" || category.Name != "Projects" {
+			t.Errorf("unexpected category %+v", category)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		category, resp := client.GetCategory("team1", "category3")
+		if category != nil {
+			t.Errorf("expected no category, got %+v", category)
+		}
+		var errNotFound *ErrNotFound
+		if !errors.As(resp.Error, &errNotFound) {
+			t.Errorf("expected an ErrNotFound, got %v", resp.Error)
+		}
+	})
+}
+
+func TestSetBoardMemberRole(t *testing.T) {
+	t.Run("only changes the scheme flags", func(t *testing.T) {
+		member := &BoardMember{
+			BoardID:      "board1",
+			UserID:       "user1",
+			Roles:        "custom_role",
+			MinimumRole:  "viewer",
+			SchemeAdmin:  true,
+			SchemeEditor: true,
+			SchemeViewer: true,
+		}
+		var updated BoardMember
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method + " " + r.URL.Path {
+			case "GET /api/v2/boards/board1/members":
+				writeJSON(t, w, http.StatusOK, []*BoardMember{{BoardID: "board1", UserID: "user2"}, member})
+			case "PUT /api/v2/boards/board1/members/user1":
+				_ = json.NewDecoder(r.Body).Decode(&updated)
+				writeJSON(t, w, http.StatusOK, updated)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+
+		result, resp := client.SetBoardMemberRole("board1", "user1", BoardRoleCommenter)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+
+		expected := BoardMember{
+			BoardID:         "board1",
+			UserID:          "user1",
+			Roles:           "custom_role",
+			MinimumRole:     "viewer",
+			SchemeCommenter: true,
+			SchemeViewer:    true,
+		}
+		if updated != expected {
+			t.Errorf("expected %+v to be sent, got %+v", expected, updated)
+		}
+		if result == nil || *result != expected {
+			t.Errorf("expected %+v to be returned, got %+v", expected, result)
+		}
+	})
+
+	t.Run("invalid role", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			writeJSON(t, w, http.StatusOK, []*BoardMember{{BoardID: "board1", UserID: "user1"}})
+		})
+
+		_, resp := client.SetBoardMemberRole("board1", "user1", BoardRoleNone)
+		var errInvalid InvalidBoardErr
+		if !errors.As(resp.Error, &errInvalid) {
+			t.Errorf("expected an InvalidBoardErr, got %v", resp.Error)
+		}
+	})
+
+	t.Run("unknown member", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			writeJSON(t, w, http.StatusOK, []*BoardMember{{BoardID: "board1", UserID: "user2"}})
+		})
+
+		_, resp := client.SetBoardMemberRole("board1", "user1", BoardRoleEditor)
+		var errNotFound *ErrNotFound
+		if !errors.As(resp.Error, &errNotFound) {
+			t.Errorf("expected an ErrNotFound, got %v", resp.Error)
+		}
+	})
+}
+
+func TestTeamUploadFile(t *testing.T) {
+	t.Run("uploads a png", func(t *testing.T) {
+		var fileName string
+		var content []byte
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/api/v2/teams/team1/board1/files" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			file, header, err := r.FormFile(UploadFormFileKey)
+			if err != nil {
+				t.Errorf("expected a file: %s", err)
+				return
+			}
+			defer file.Close()
+			fileName = header.Filename
+			content, _ = io.ReadAll(file)
+			writeJSON(t, w, http.StatusOK, FileUploadResponse{FileID: "file1"})
+		})
+
+		res, resp := client.TeamUploadFile("team1", "board1", "image.png", strings.NewReader("png data"), 100)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if res.FileID != "file1" {
+			t.Errorf("expected file file1, got %q", res.FileID)
+		}
+		if fileName != "image.png" || string(content) != "png data" {
+			t.Errorf("unexpected file %q with content %q", fileName, content)
+		}
+	})
+
+	t.Run("rejects html without sending", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		_, resp := client.TeamUploadFile("team1", "board1", "page.html", strings.NewReader("<html></html>"), 0)
+		if !errors.Is(resp.Error, ErrUnsafeContentType) {
+			t.Errorf("expected ErrUnsafeContentType, got %v", resp.Error)
+		}
+	})
+
+	t.Run("rejects files over the maximum size without sending", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		_, resp := client.TeamUploadFile("team1", "board1", "image.png", strings.NewReader("12345"), 4)
+		if !errors.Is(resp.Error, ErrFileTooLarge) {
+			t.Errorf("expected ErrFileTooLarge, got %v", resp.Error)
+		}
+	})
+}
+
+func TestRegister(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   int
+		body     interface{}
+		expected error
+	}{
+		{
+			name:     "user already exists",
+			status:   http.StatusConflict,
+			body:     ErrorResponse{Error: "The username is taken", ErrorCode: http.StatusConflict},
+			expected: ErrUserAlreadyExists,
+		},
+		{
+			name:     "invalid invite token",
+			status:   http.StatusUnauthorized,
+			body:     ErrorResponse{Error: "invalid token", ErrorCode: http.StatusUnauthorized},
+			expected: ErrInvalidInviteToken,
+		},
+		{
+			name:     "status without an error code",
+			status:   http.StatusUnauthorized,
+			body:     struct{}{},
+			expected: ErrInvalidInviteToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, tc.status, tc.body)
+			})
+
+			success, resp := client.Register(&RegisterRequest{Username: "alice", Password: "secret"})
+			if success {
+				t.Error("expected the registration to fail")
+			}
+			if !errors.Is(resp.Error, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, resp.Error)
+			}
+			var errReader RequestReaderError
+			if !errors.As(resp.Error, &errReader) {
+				t.Errorf("expected the original error to be wrapped, got %v", resp.Error)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+		})
+	}
+
+	t.Run("messages are not classified", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusBadRequest, "the token already expired")
+		})
+
+		_, resp := client.Register(&RegisterRequest{Username: "alice", Password: "secret"})
+		if resp.Error == nil {
+			t.Fatal("expected an error")
+		}
+		if errors.Is(resp.Error, ErrUserAlreadyExists) || errors.Is(resp.Error, ErrInvalidInviteToken) {
+			t.Errorf("expected an unclassified error, got %v", resp.Error)
+		}
+	})
+
+	t.Run("success", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, http.StatusOK, struct{}{})
+		})
+
+		success, resp := client.Register(&RegisterRequest{Username: "alice", Password: "secret"})
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Error("expected the registration to succeed")
+		}
+	})
+}
+
+func TestGetTemplatesSummary(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v2/teams/team1/templates" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		writeJSON(t, w, http.StatusOK, []*Board{
+			{
+				ID:          "template1",
+				Title:       "Roadmap",
+				Icon:        "🗺",
+				Description: "Plan the roadmap",
+				IsTemplate:  true,
+				CardProperties: []map[string]interface{}{
+					{"id": "status", "name": "Status", "type": "select"},
+				},
+			},
+		})
+	})
+
+	summaries, resp := client.GetTemplatesSummary("team1")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := []*BoardSummary{{
+		ID:          "template1",
+		Title:       "Roadmap",
+		Icon:        "🗺",
+		Description: "Plan the roadmap",
+		IsTemplate:  true,
+	}}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("expected %+v, got %+v", expected[0], summaries)
+	}
+}
+
+func TestJoinBoard(t *testing.T) {
+	t.Run("empty successful body", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/api/v2/boards/board1/join" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+
+		member, resp := client.JoinBoard("board1")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if member != nil {
+			t.Errorf("expected no member, got %+v", member)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+	})
+
+	t.Run("member in the body", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, http.StatusOK, BoardMember{BoardID: "board1", UserID: "user1", SchemeEditor: true})
+		})
+
+		member, resp := client.JoinBoard("board1")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if member == nil || member.UserID != "user1" || !member.SchemeEditor {
+			t.Errorf("unexpected member %+v", member)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("{"))
+		})
+
+		member, resp := client.JoinBoard("board1")
+		if member != nil {
+			t.Errorf("expected no member, got %+v", member)
+		}
+		var decodeErr *DecodeError
+		if !errors.As(resp.Error, &decodeErr) {
+			t.Errorf("expected a DecodeError, got %v", resp.Error)
+		}
+	})
+}
+
+func TestWithAuthScheme(t *testing.T) {
+	testCases := []struct {
+		name     string
+		opts     []ClientOption
+		expected string
+	}{
+		{
+			name:     "default scheme",
+			expected: "Bearer test-token",
+		},
+		{
+			name:     "custom scheme",
+			opts:     []ClientOption{WithAuthScheme("Token")},
+			expected: "Token test-token",
+		},
+		{
+			name:     "bare token",
+			opts:     []ClientOption{WithAuthScheme("")},
+			expected: "test-token",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var authorization string
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+			}, tc.opts...)
+
+			if _, resp := client.GetMe(); resp.Error != nil {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if authorization != tc.expected {
+				t.Errorf("expected Authorization %q, got %q", tc.expected, authorization)
+			}
+		})
+	}
+
+	t.Run("no token", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Header["Authorization"]; ok {
+				t.Errorf("expected no Authorization header, got %q", r.Header.Get("Authorization"))
+			}
+			writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+		}))
+		defer server.Close()
+
+		if _, resp := NewClient(server.URL, "").GetMe(); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+	})
+}
+
+func TestUpsertSubscription(t *testing.T) {
+	subs := []*Subscription{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v2/subscriptions":
+			var sub *Subscription
+			_ = json.NewDecoder(r.Body).Decode(&sub)
+			for _, existing := range subs {
+				if existing.BlockID == sub.BlockID && existing.SubscriberID == sub.SubscriberID {
+					writeError(t, w, http.StatusConflict, "subscription already exists")
+					return
+				}
+			}
+			sub.CreateAt = int64(len(subs) + 1)
+			subs = append(subs, sub)
+			writeJSON(t, w, http.StatusOK, sub)
+		case "GET /api/v2/subscriptions/user1":
+			writeJSON(t, w, http.StatusOK, subs)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	sub := &Subscription{BlockType: TypeCard, BlockID: "card1", SubscriberType: SubTypeUser, SubscriberID: "user1"}
+
+	created, resp := client.UpsertSubscription(sub)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if created == nil || created.BlockID != "card1" || created.CreateAt != 1 {
+		t.Fatalf("unexpected subscription %+v", created)
+	}
+
+	existing, resp := client.UpsertSubscription(sub)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, resp.StatusCode)
+	}
+	if !reflect.DeepEqual(existing, created) {
+		t.Errorf("expected the existing subscription %+v, got %+v", created, existing)
+	}
+	if len(subs) != 1 {
+		t.Errorf("expected a single subscription, got %d", len(subs))
+	}
+}
+
+func TestGetCardHistory(t *testing.T) {
+	t.Run("decodes the revisions newest first", func(t *testing.T) {
+		var query url.Values
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet || r.URL.Path != "/api/v2/cards/card1/history" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			query = r.URL.Query()
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[
+				{"id": "card1", "boardId": "board1", "type": "card", "title": "Draft", "updateAt": 100, "modifiedBy": "user1"},
+				{"id": "card1", "boardId": "board1", "type": "card", "title": "Final", "updateAt": 300, "modifiedBy": "user2"},
+				{"id": "card1", "boardId": "board1", "type": "card", "title": "Review", "updateAt": 200, "modifiedBy": "user1"}
+			]`))
+		})
+
+		revisions, resp := client.GetCardHistory("card1", 1, 3)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if query.Get("page") != "1" || query.Get("per_page") != "3" {
+			t.Errorf("unexpected query %v", query)
+		}
+		if len(revisions) != 3 {
+			t.Fatalf("expected 3 revisions, got %d", len(revisions))
+		}
+		for i, expected := range []struct {
+			title    string
+			updateAt int64
+		}{{"Final", 300}, {"Review", 200}, {"Draft", 100}} {
+			if revisions[i].Title != expected.title || revisions[i].UpdateAt != expected.updateAt {
+				t.Errorf("expected revision %d to be %q at %d, got %q at %d",
+					i, expected.title, expected.updateAt, revisions[i].Title, revisions[i].UpdateAt)
+			}
+		}
+		if revisions[0].ModifiedBy != "user2" {
+			t.Errorf("expected the last revision to be modified by user2, got %q", revisions[0].ModifiedBy)
+		}
+	})
+
+	t.Run("history disabled", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusNotImplemented, "history is disabled")
+		})
+
+		_, resp := client.GetCardHistory("card1", 0, 10)
+		var errNotImplemented *ErrNotImplemented
+		if !errors.As(resp.Error, &errNotImplemented) {
+			t.Errorf("expected an ErrNotImplemented, got %v", resp.Error)
+		}
+		if resp.StatusCode != http.StatusNotImplemented {
+			t.Errorf("expected status %d, got %d", http.StatusNotImplemented, resp.StatusCode)
+		}
+	})
+
+	t.Run("route not supported", func(t *testing.T) {
+		client := newTestClient(t, http.NotFound)
+
+		_, resp := client.GetCardHistory("card1", 0, 10)
+		var errNotImplemented *ErrNotImplemented
+		if !errors.As(resp.Error, &errNotImplemented) {
+			t.Errorf("expected an ErrNotImplemented, got %v", resp.Error)
+		}
+	})
+
+	t.Run("card not found", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusNotFound, "card not found")
+		})
+
+		_, resp := client.GetCardHistory("card1", 0, 10)
+		var errNotImplemented *ErrNotImplemented
+		if resp.Error == nil || errors.As(resp.Error, &errNotImplemented) {
+			t.Errorf("expected a not found error, got %v", resp.Error)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+}
+
+func TestPatchBlocksAtomic(t *testing.T) {
+	title1, title2 := "Block 1", "Block 2"
+	ids := []string{"block1", "block2"}
+	patches := []*BlockPatch{{Title: &title1}, {Title: &title2}}
+
+	t.Run("validates the slices", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		success, resp := client.PatchBlocksAtomic("board1", ids, patches[:1])
+		if success {
+			t.Error("expected the patch to fail")
+		}
+		if !errors.Is(resp.Error, ErrBlockIDsAndPatchesMissmatchInBoardsAndBlocks) {
+			t.Errorf("expected ErrBlockIDsAndPatchesMissmatchInBoardsAndBlocks, got %v", resp.Error)
+		}
+
+		success, resp = client.PatchBlocksAtomic("board1", nil, nil)
+		if !success || resp.Error != nil {
+			t.Errorf("expected an empty patch to succeed, got %v", resp.Error)
+		}
+	})
+
+	t.Run("atomic path", func(t *testing.T) {
+		var pbab PatchBoardsAndBlocks
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPatch || r.URL.Path != "/api/v2/boards-and-blocks" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			_ = json.NewDecoder(r.Body).Decode(&pbab)
+			writeJSON(t, w, http.StatusOK, BoardsAndBlocks{})
+		})
+
+		success, resp := client.PatchBlocksAtomic("board1", ids, patches)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Error("expected the patch to succeed")
+		}
+		if !reflect.DeepEqual(pbab.BoardIDs, []string{"board1"}) || len(pbab.BoardPatches) != 1 {
+			t.Errorf("expected an empty patch for board1, got %v and %+v", pbab.BoardIDs, pbab.BoardPatches)
+		}
+		if !reflect.DeepEqual(pbab.BlockIDs, ids) || !reflect.DeepEqual(pbab.BlockPatches, patches) {
+			t.Errorf("unexpected block patches %v and %+v", pbab.BlockIDs, pbab.BlockPatches)
+		}
+	})
+
+	t.Run("doesn't fall back on not found", func(t *testing.T) {
+		requests := 0
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			writeError(t, w, http.StatusNotFound, "block not found")
+		})
+
+		success, resp := client.PatchBlocksAtomic("board1", ids, patches)
+		if success {
+			t.Error("expected the patch to fail")
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+		if requests != 1 {
+			t.Errorf("expected a single request, got %d", requests)
+		}
+	})
+
+	t.Run("falls back to per block patches", func(t *testing.T) {
+		patched := []string{}
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v2/boards-and-blocks":
+				writeError(t, w, http.StatusMethodNotAllowed, "method not allowed")
+			case "/api/v2/boards/board1/blocks/block1":
+				patched = append(patched, "block1")
+				writeJSON(t, w, http.StatusOK, struct{}{})
+			case "/api/v2/boards/board1/blocks/block2":
+				writeError(t, w, http.StatusForbidden, "forbidden")
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		})
+
+		success, resp := client.PatchBlocksAtomic("board1", ids, patches)
+		if success {
+			t.Error("expected the patch to fail")
+		}
+		var errPartial PartialBlockPatchErr
+		if !errors.As(resp.Error, &errPartial) {
+			t.Fatalf("expected a PartialBlockPatchErr, got %v", resp.Error)
+		}
+		if !reflect.DeepEqual(errPartial.PatchedIDs, []string{"block1"}) || errPartial.FailedID != "block2" {
+			t.Errorf("unexpected partial patch %+v", errPartial)
+		}
+		if !reflect.DeepEqual(patched, []string{"block1"}) {
+			t.Errorf("expected block1 to be patched, got %v", patched)
+		}
+	})
+}
+
+func TestGetRaw(t *testing.T) {
+	t.Run("streams the body in chunks", func(t *testing.T) {
+		chunks := []string{"first chunk,", "second chunk,", "last chunk"}
+		next := make(chan struct{})
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v2/boards/board1/archive/export" {
+				t.Errorf("unexpected path %q", r.URL.Path)
+			}
+			for i, chunk := range chunks {
+				if i > 0 {
+					// wait until the client has read the previous chunk
+					<-next
+				}
+				_, _ = w.Write([]byte(chunk))
+				w.(http.Flusher).Flush()
+			}
+		})
+
+		r, resp := client.GetRaw("/boards/board1/archive/export")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		defer r.Body.Close()
+
+		for i, chunk := range chunks {
+			buf := make([]byte, len(chunk))
+			if _, err := io.ReadFull(r.Body, buf); err != nil {
+				t.Fatalf("failed to read chunk %d: %s", i, err)
+			}
+			if string(buf) != chunk {
+				t.Errorf("expected chunk %q, got %q", chunk, buf)
+			}
+			if i < len(chunks)-1 {
+				next <- struct{}{}
+			}
+		}
+		if rest, _ := io.ReadAll(r.Body); len(rest) != 0 {
+			t.Errorf("expected the body to be consumed, got %q", rest)
+		}
+	})
+
+	t.Run("errors return no response", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusNotFound, "not found")
+		})
+
+		r, resp := client.GetRaw("/boards/board1/archive/export")
+		if r != nil {
+			t.Errorf("expected no response, got %+v", r)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+}
+
+// newTestFavoritesServer extends the category server with the routes
+// needed by the favorite helpers. Created categories are appended to
+// categoryBoards.
+func newTestFavoritesServer(t *testing.T, categoryBoards *[]CategoryBoards) *Client {
+	t.Helper()
+
+	boards := []*Board{{ID: "board1"}, {ID: "board2"}, {ID: "board3"}}
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v2/teams/team1/categories":
+			writeJSON(t, w, http.StatusOK, *categoryBoards)
+		case "POST /api/v2/teams/team1/categories":
+			var category Category
+			_ = json.NewDecoder(r.Body).Decode(&category)
+			category.ID = "favorites"
+			*categoryBoards = append(*categoryBoards, CategoryBoards{Category: category, BoardMetadata: []CategoryBoardMetadata{}})
+			writeJSON(t, w, http.StatusOK, category)
+		case "GET /api/v2/users/me":
+			writeJSON(t, w, http.StatusOK, User{ID: "user1"})
+		case "GET /api/v2/teams/team1/boards":
+			writeJSON(t, w, http.StatusOK, boards)
+		default:
+			parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v2/teams/team1/categories/"), "/")
+			if r.Method != http.MethodPost || len(parts) != 3 || parts[1] != "boards" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+				return
+			}
+			moveCategoryBoard(*categoryBoards, parts[0], parts[2])
+			writeJSON(t, w, http.StatusOK, struct{}{})
+		}
+	})
+}
+
+func TestFavoriteBoards(t *testing.T) {
+	categoryBoards := newTestCategoryBoards()
+	client := newTestFavoritesServer(t, &categoryBoards)
+
+	favorites, resp := client.GetFavoriteBoards("team1")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if len(favorites) != 0 {
+		t.Errorf("expected no favorites, got %+v", favorites)
+	}
+
+	for _, boardID := range []string{"board3", "board1"} {
+		if resp := client.FavoriteBoard("team1", boardID); resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+	}
+	if len(categoryBoards) != 3 || categoryBoards[2].Name != FavoritesCategoryName || categoryBoards[2].Type != CategoryTypeSystem {
+		t.Fatalf("expected the favorites category to be created, got %+v", categoryBoards)
+	}
+
+	favorites, resp = client.GetFavoriteBoards("team1")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if len(favorites) != 2 || favorites[0].ID != "board3" || favorites[1].ID != "board1" {
+		t.Errorf("expected board3 and board1 to be favorites, got %+v", favorites)
+	}
+
+	if resp := client.UnfavoriteBoard("team1", "board3"); resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if ids := categoryBoardIDs(categoryBoards, "favorites"); !reflect.DeepEqual(ids, []string{"board1"}) {
+		t.Errorf("expected only board1 to be a favorite, got %v", ids)
+	}
+	if ids := categoryBoardIDs(categoryBoards, "category1"); !reflect.DeepEqual(ids, []string{"board3"}) {
+		t.Errorf("expected board3 to be moved to the default category, got %v", ids)
+	}
+}
+
+func TestUnfavoriteBoardNotFavorite(t *testing.T) {
+	categoryBoards := newTestCategoryBoards()
+	client := newTestFavoritesServer(t, &categoryBoards)
+
+	resp := client.UnfavoriteBoard("team1", "board2")
+	var errNotFound *ErrNotFound
+	if !errors.As(resp.Error, &errNotFound) {
+		t.Errorf("expected an ErrNotFound, got %v", resp.Error)
+	}
+	if ids := categoryBoardIDs(categoryBoards, "category2"); !reflect.DeepEqual(ids, []string{"board2", "board3"}) {
+		t.Errorf("expected the custom category to be untouched, got %v", ids)
+	}
+}
+
+func TestPatchToJSON(t *testing.T) {
+	title := "Renamed"
+	invalidType := BlockType("unknown")
+	icon := "🐛🐛"
+
+	minimalBlockPatch := &BlockPatch{Title: &title}
+	minimalBoardPatch := &BoardPatch{Title: &title}
+	emptyBlockPatch := &BlockPatch{}
+	emptyCardPatch := &CardPatch{}
+	emptyBoardPatch := &BoardPatch{}
+	emptyPbab := &PatchBoardsAndBlocks{}
+	var nilBlockPatch *BlockPatch
+	nilInnerPbab := &PatchBoardsAndBlocks{
+		BoardIDs:     []string{"board1"},
+		BoardPatches: []*BoardPatch{nil},
+	}
+
+	testCases := []struct {
+		name          string
+		patch         interface{}
+		validate      func() error
+		expectedJSON  string
+		expectedError error
+	}{
+		{name: "minimal block patch", patch: minimalBlockPatch, validate: minimalBlockPatch.IsValid, expectedJSON: `{"title":"Renamed"}`},
+		{name: "minimal board patch", patch: minimalBoardPatch, validate: minimalBoardPatch.IsValid, expectedJSON: `{"title":"Renamed"}`},
+		{name: "empty block patch", patch: emptyBlockPatch, validate: emptyBlockPatch.IsValid, expectedError: ErrEmptyPatch},
+		{name: "empty card patch", patch: emptyCardPatch, validate: emptyCardPatch.CheckValid, expectedError: ErrEmptyPatch},
+		{name: "empty board patch", patch: emptyBoardPatch, validate: emptyBoardPatch.IsValid, expectedError: ErrEmptyPatch},
+		{name: "empty boards and blocks patch", patch: emptyPbab, validate: emptyPbab.IsValid, expectedError: ErrNoBoardsInBoardsAndBlocks},
+		{name: "nil block patch", patch: nilBlockPatch, validate: nilBlockPatch.IsValid, expectedError: ErrEmptyPatch},
+		{name: "nil inner patch", patch: nilInnerPbab, validate: nilInnerPbab.IsValid, expectedError: ErrEmptyPatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := patchToJSON(tc.patch, tc.validate)
+			if !errors.Is(err, tc.expectedError) {
+				t.Fatalf("expected error %v, got %v", tc.expectedError, err)
+			}
+			if data != tc.expectedJSON {
+				t.Errorf("expected %s, got %s", tc.expectedJSON, data)
+			}
+		})
+	}
+
+	t.Run("invalid block type", func(t *testing.T) {
+		patch := &BlockPatch{Type: &invalidType}
+		if _, err := patchToJSON(patch, patch.IsValid); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("invalid card icon", func(t *testing.T) {
+		patch := &CardPatch{Icon: &icon}
+		var errCard ErrInvalidCard
+		if _, err := patchToJSON(patch, patch.CheckValid); !errors.As(err, &errCard) {
+			t.Errorf("expected an ErrInvalidCard, got %v", err)
+		}
+	})
+
+	t.Run("invalid inner block patch", func(t *testing.T) {
+		patch := &PatchBoardsAndBlocks{
+			BoardIDs:     []string{"board1"},
+			BoardPatches: []*BoardPatch{{}},
+			BlockIDs:     []string{"block1"},
+			BlockPatches: []*BlockPatch{{Type: &invalidType}},
+		}
+		if _, err := patchToJSON(patch, patch.IsValid); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestPatchBlock(t *testing.T) {
+	title := "Renamed"
+
+	t.Run("sends only the set fields", func(t *testing.T) {
+		var body string
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPatch || r.URL.Path != "/api/v2/boards/board1/blocks/block1" {
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+			b, _ := io.ReadAll(r.Body)
+			body = string(b)
+			writeJSON(t, w, http.StatusOK, struct{}{})
+		})
+
+		success, resp := client.PatchBlock("board1", "block1", &BlockPatch{Title: &title}, false)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Error("expected the patch to succeed")
+		}
+		if body != `{"title":"Renamed"}` {
+			t.Errorf("unexpected body %s", body)
+		}
+	})
+
+	t.Run("rejects an empty patch", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		})
+
+		success, resp := client.PatchBlock("board1", "block1", &BlockPatch{}, false)
+		if success {
+			t.Error("expected the patch to fail")
+		}
+		if !errors.Is(resp.Error, ErrEmptyPatch) {
+			t.Errorf("expected ErrEmptyPatch, got %v", resp.Error)
+		}
+	})
+}
+
+func TestResolveMentions(t *testing.T) {
+	users := []*User{
+		{ID: "user1", Username: "alice"},
+		{ID: "user2", Username: "alice2"},
+		{ID: "user3", Username: "Bob"},
+	}
+
+	searches := []string{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v2/teams/team1/users" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		search := r.URL.Query().Get("search")
+		searches = append(searches, search)
+
+		found := []*User{}
+		for _, user := range users {
+			if strings.HasPrefix(strings.ToLower(user.Username), search) {
+				found = append(found, user)
+			}
+		}
+		writeJSON(t, w, http.StatusOK, found)
+	})
+
+	mentions, resp := client.ResolveMentions("team1", "@alice and @BOB, ask @carol")
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	expected := map[string]string{"alice": "user1", "bob": "user3", "carol": ""}
+	if !reflect.DeepEqual(mentions, expected) {
+		t.Errorf("expected %v, got %v", expected, mentions)
+	}
+	if !reflect.DeepEqual(searches, []string{"alice", "bob", "carol"}) {
+		t.Errorf("unexpected searches %v", searches)
+	}
+
+	t.Run("no mentions", func(t *testing.T) {
+		searches = searches[:0]
+		mentions, resp := client.ResolveMentions("team1", "no mentions")
+		if resp.Error != nil || len(mentions) != 0 {
+			t.Errorf("expected no mentions, got %v and %v", mentions, resp.Error)
+		}
+		if len(searches) != 0 {
+			t.Errorf("expected no requests, got %v", searches)
+		}
+	})
+
+	t.Run("search error", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeError(t, w, http.StatusForbidden, "forbidden")
+		})
+
+		mentions, resp := client.ResolveMentions("team1", "@alice")
+		if mentions != nil {
+			t.Errorf("expected no mentions, got %v", mentions)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected status %d, got %d", http.StatusForbidden, resp.StatusCode)
+		}
+	})
+}
+
+// newTestArchive builds an archive holding the given boards, each with
+// a single card.
+func newTestArchive(t *testing.T, boards ...*Board) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	writeFile := func(name string, lines ...interface{}) {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("failed to create %s: %s", name, err)
+		}
+		for _, line := range lines {
+			if err := json.NewEncoder(f).Encode(line); err != nil {
+				t.Fatalf("failed to write %s: %s", name, err)
+			}
+		}
+	}
+
+	writeFile("version.json", ArchiveHeader{Version: 2, Date: 1})
+	for _, board := range boards {
+		card := &Block{ID: "card-" + board.ID, BoardID: board.ID, Type: TypeCard}
+		writeFile(board.ID+"/board.jsonl",
+			ArchiveLine{Type: "board", Data: json.RawMessage(toJSON(board))},
+			ArchiveLine{Type: "block", Data: json.RawMessage(toJSON(card))},
+		)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close the archive: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// newTestArchiveServer serves the boards of each team, exports boards as
+// archives and imports archives as new boards of the team. If concurrent
+// is not nil, it is created along with the imported boards, as if another
+// client created it at the same time.
+func newTestArchiveServer(t *testing.T, teamBoards map[string][]*Board, concurrent *Board) *Client {
+	t.Helper()
+
+	imported := 0
+	return newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v2/"), "/")
+		switch {
+		case r.Method == http.MethodGet && len(parts) == 3 && parts[0] == "teams" && parts[2] == "boards":
+			boards := teamBoards[parts[1]]
+			if boards == nil {
+				boards = []*Board{}
+			}
+			writeJSON(t, w, http.StatusOK, boards)
+		case r.Method == http.MethodGet && len(parts) == 4 && parts[0] == "boards" && parts[2] == "archive" && parts[3] == "export":
+			for _, boards := range teamBoards {
+				for _, board := range boards {
+					if board.ID == parts[1] {
+						_, _ = w.Write(newTestArchive(t, board))
+						return
+					}
+				}
+			}
+			writeError(t, w, http.StatusNotFound, "board not found")
+		case r.Method == http.MethodPost && len(parts) == 4 && parts[0] == "teams" && parts[2] == "archive" && parts[3] == "import":
+			file, _, err := r.FormFile(UploadFormFileKey)
+			if err != nil {
+				writeError(t, w, http.StatusBadRequest, err.Error())
+				return
+			}
+			defer file.Close()
+			data, _ := io.ReadAll(file)
+			archived, err := BoardsFromArchive(data)
+			if err != nil {
+				writeError(t, w, http.StatusBadRequest, "invalid archive")
+				return
+			}
+
+			// the concurrent board is listed first so that it would be
+			// picked if boards were matched by ID only
+			if concurrent != nil {
+				teamBoards[parts[1]] = append(teamBoards[parts[1]], concurrent)
+			}
+			for _, board := range archived {
+				imported++
+				restored := &Board{ID: "imported" + strconv.Itoa(imported), TeamID: parts[1], Title: board.Title}
+				teamBoards[parts[1]] = append(teamBoards[parts[1]], restored)
+			}
+			writeJSON(t, w, http.StatusOK, struct{}{})
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+}
+
+func TestBoardSnapshot(t *testing.T) {
+	t.Run("round trip to another team", func(t *testing.T) {
+		teamBoards := map[string][]*Board{
+			"team1": {{ID: "board1", TeamID: "team1", Title: "Roadmap"}},
+			"team2": {{ID: "board2", TeamID: "team2", Title: "Backlog"}},
+		}
+		client := newTestArchiveServer(t, teamBoards, nil)
+
+		snapshot, resp := client.SnapshotBoard("board1")
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+
+		board, resp := client.RestoreBoardSnapshot("team2", snapshot)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if board.ID != "imported1" || board.TeamID != "team2" || board.Title != "Roadmap" {
+			t.Errorf("unexpected restored board %+v", board)
+		}
+		if len(teamBoards["team1"]) != 1 || len(teamBoards["team2"]) != 2 {
+			t.Errorf("expected the board to be added to team2 only, got %v", teamBoards)
+		}
+	})
+
+	t.Run("board created concurrently", func(t *testing.T) {
+		teamBoards := map[string][]*Board{}
+		concurrent := &Board{ID: "other", TeamID: "team2", Title: "Other"}
+		client := newTestArchiveServer(t, teamBoards, concurrent)
+
+		snapshot := newTestArchive(t, &Board{ID: "board1", Title: "Roadmap"})
+		board, resp := client.RestoreBoardSnapshot("team2", snapshot)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if board.ID != "imported1" {
+			t.Errorf("expected the imported board to be returned, got %+v", board)
+		}
+	})
+
+	t.Run("several boards", func(t *testing.T) {
+		teamBoards := map[string][]*Board{}
+		client := newTestArchiveServer(t, teamBoards, &Board{ID: "other", TeamID: "team2", Title: "Other"})
+
+		archive := newTestArchive(t,
+			&Board{ID: "board1", Title: "Roadmap"},
+			&Board{ID: "board2", Title: "Backlog"},
+		)
+		boards, resp := client.ImportArchiveBoards("team2", archive)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if len(boards) != 2 || boards[0].Title != "Roadmap" || boards[1].Title != "Backlog" {
+			t.Fatalf("expected the boards in archive order, got %+v", boards)
+		}
+		if boards[0].ID != "imported1" || boards[1].ID != "imported2" {
+			t.Errorf("unexpected imported boards %+v", boards)
+		}
+
+		board, resp := client.RestoreBoardSnapshot("team2", archive)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if board.ID != "imported3" || board.Title != "Roadmap" {
+			t.Errorf("expected the first archived board to be returned, got %+v", board)
+		}
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		client := newTestArchiveServer(t, map[string][]*Board{}, nil)
+
+		board, resp := client.RestoreBoardSnapshot("team2", []byte("not an archive"))
+		if board != nil {
+			t.Errorf("expected no board, got %+v", board)
+		}
+		if resp.Error == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("unknown board", func(t *testing.T) {
+		client := newTestArchiveServer(t, map[string][]*Board{}, nil)
+
+		if _, resp := client.SnapshotBoard("board1"); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status %d, got %d", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+}
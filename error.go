@@ -26,6 +26,12 @@ var (
 	ErrRequestEntityTooLarge = errors.New("request entity too large")
 
 	ErrInvalidBoardSearchField = errors.New("invalid board search field")
+
+	ErrPurgeNotConfirmed = errors.New("permanent deletion must be confirmed")
+
+	ErrEmptyPatch = errors.New("patch doesn't change any field")
+
+	ErrNoBatchResponse = errors.New("batch operation returned no response")
 )
 
 // ErrNotFound is an error type that can be returned by store APIs
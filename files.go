@@ -5,12 +5,22 @@ package boards
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
+	"strings"
 
 	model "github.com/mattermost/mattermost/server/public/model"
 
 )
 
+// UploadFormFileKey is the form key of the file in upload requests.
+const UploadFormFileKey = "file"
+
+var (
+	ErrUnsafeContentType = errors.New("unsafe content type")
+	ErrFileTooLarge      = errors.New("file too large")
+)
+
 var UnsafeContentTypes = [...]string{
 	"application/javascript",
 	"application/ecmascript",
@@ -33,6 +43,19 @@ var MediaContentTypes = [...]string{
 	"audio/wav",
 }
 
+// IsUnsafeContentType returns true if the content type, ignoring its
+// parameters, is one of the UnsafeContentTypes.
+func IsUnsafeContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	for _, unsafeType := range UnsafeContentTypes {
+		if mediaType == unsafeType {
+			return true
+		}
+	}
+	return false
+}
+
 // FileUploadResponse is the response to a file upload
 // swagger:model
 type FileUploadResponse struct {
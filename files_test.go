@@ -0,0 +1,26 @@
+package boards
+
+import "testing"
+
+func TestIsUnsafeContentType(t *testing.T) {
+	testCases := []struct {
+		contentType string
+		unsafe      bool
+	}{
+		{"text/html", true},
+		{"text/html; charset=utf-8", true},
+		{"TEXT/JavaScript", true},
+		{"application/javascript", true},
+		{"image/png", false},
+		{"text/plain", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.contentType, func(t *testing.T) {
+			if unsafe := IsUnsafeContentType(tc.contentType); unsafe != tc.unsafe {
+				t.Errorf("expected %t, got %t", tc.unsafe, unsafe)
+			}
+		})
+	}
+}
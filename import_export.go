@@ -1,15 +1,25 @@
 package boards
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 )
 
 var (
 	ErrInvalidImageBlock = errors.New("invalid image block")
 )
 
+const (
+	archiveBoardFileName = "board.jsonl"
+	archiveLineTypeBoard = "board"
+)
+
 // Archive is an import / export archive.
 // TODO: remove once default templates are converted to new archive format.
 type Archive struct {
@@ -85,3 +95,61 @@ func NewErrUnsupportedArchiveLineType(line int, got string) ErrUnsupportedArchiv
 func (e ErrUnsupportedArchiveLineType) Error() string {
 	return fmt.Sprintf("unsupported archive line type; got %s, line %d", e.got, e.line)
 }
+
+// BoardsFromArchive returns the boards contained in an archive, in the
+// order in which they are stored. Each board is read from the board line
+// of its `<boardID>/board.jsonl` file, the other lines are ignored.
+func BoardsFromArchive(archive []byte) ([]*Board, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, err
+	}
+
+	boards := []*Board{}
+	for _, file := range zr.File {
+		if !strings.HasSuffix(file.Name, "/"+archiveBoardFileName) {
+			continue
+		}
+
+		board, err := boardFromArchiveFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", file.Name, err)
+		}
+		if board != nil {
+			boards = append(boards, board)
+		}
+	}
+	return boards, nil
+}
+
+func boardFromArchiveFile(file *zip.File) (*Board, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	reader := bufio.NewReader(rc)
+	for {
+		data, readErr := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) != 0 {
+			var line ArchiveLine
+			if err := json.Unmarshal(data, &line); err != nil {
+				return nil, err
+			}
+			if line.Type == archiveLineTypeBoard {
+				var board Board
+				if err := json.Unmarshal(line.Data, &board); err != nil {
+					return nil, err
+				}
+				return &board, nil
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return nil, nil
+		}
+		if readErr != nil {
+			return nil, readErr
+		}
+	}
+}
@@ -29,6 +29,21 @@ type Sharing struct {
 	UpdateAt int64 `json:"update_at,omitempty"`
 }
 
+// NewSharing creates the sharing information for a board, ready to be
+// sent with PostSharing. A new access token is generated when enabling
+// sharing.
+func NewSharing(boardID string, enabled bool) *Sharing {
+	sharing := &Sharing{
+		ID:       boardID,
+		Enabled:  enabled,
+		UpdateAt: GetMillis(),
+	}
+	if enabled {
+		sharing.Token = NewID(IDTypeToken)
+	}
+	return sharing
+}
+
 func SharingFromJSON(data io.Reader) Sharing {
 	var sharing Sharing
 	_ = json.NewDecoder(data).Decode(&sharing)
@@ -0,0 +1,60 @@
+package boards
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNewSharing(t *testing.T) {
+	t.Run("enabled sharing has a token", func(t *testing.T) {
+		sharing := NewSharing("board1", true)
+		if sharing.ID != "board1" {
+			t.Errorf("expected ID board1, got %q", sharing.ID)
+		}
+		if !sharing.Enabled {
+			t.Error("expected sharing to be enabled")
+		}
+		if sharing.Token == "" {
+			t.Error("expected a token to be generated")
+		}
+		if sharing.UpdateAt == 0 {
+			t.Error("expected UpdateAt to be set")
+		}
+	})
+
+	t.Run("disabled sharing has no token", func(t *testing.T) {
+		sharing := NewSharing("board1", false)
+		if sharing.Enabled {
+			t.Error("expected sharing to be disabled")
+		}
+		if sharing.Token != "" {
+			t.Errorf("expected no token, got %q", sharing.Token)
+		}
+	})
+
+	t.Run("posts to the board's sharing route", func(t *testing.T) {
+		var path string
+		var posted Sharing
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&posted)
+			writeJSON(t, w, http.StatusOK, struct{}{})
+		})
+
+		sharing := NewSharing("board1", true)
+		success, resp := client.PostSharing(sharing)
+		if resp.Error != nil {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		if !success {
+			t.Error("expected the post to succeed")
+		}
+		if path != "/api/v2/boards/board1/sharing" {
+			t.Errorf("unexpected path %q", path)
+		}
+		if posted.ID != "board1" || posted.Token != sharing.Token {
+			t.Errorf("unexpected sharing posted: %+v", posted)
+		}
+	})
+}
@@ -3,6 +3,8 @@ package boards
 import (
 	"encoding/json"
 	"io"
+	"regexp"
+	"strings"
 )
 
 const (
@@ -94,6 +96,24 @@ type Session struct {
 	UpdateAt    int64                  `json:"update_at,omitempty"`
 }
 
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([a-zA-Z0-9._-]+)`)
+
+// ExtractMentions returns the deduplicated usernames mentioned with
+// @username in the text, lowercased and in order of appearance.
+func ExtractMentions(text string) []string {
+	usernames := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionRegexp.FindAllStringSubmatch(text, -1) {
+		username := strings.ToLower(strings.TrimRight(match[1], "."))
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+	}
+	return usernames
+}
+
 func UserFromJSON(data io.Reader) (*User, error) {
 	var user User
 	if err := json.NewDecoder(data).Decode(&user); err != nil {
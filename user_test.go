@@ -0,0 +1,28 @@
+package boards
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "no mentions", text: "nothing to see here", expected: []string{}},
+		{name: "single mention", text: "@alice please review", expected: []string{"alice"}},
+		{name: "lowercased and deduplicated", text: "@Alice and @bob, then @alice again", expected: []string{"alice", "bob"}},
+		{name: "trailing dot", text: "Thanks @john.doe.", expected: []string{"john.doe"}},
+		{name: "email addresses", text: "write to alice@example.com", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if mentions := ExtractMentions(tc.text); !reflect.DeepEqual(mentions, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, mentions)
+			}
+		})
+	}
+}